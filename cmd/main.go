@@ -3,25 +3,23 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"goScadaSur/pkg/config"
+	"goScadaSur/pkg/database"
 	"goScadaSur/pkg/fileio"
 	"goScadaSur/pkg/xmlcreator"
-	"io"
 	"log"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/tidwall/gjson"
 	"golang.org/x/term"
 )
 
@@ -29,26 +27,44 @@ const (
 	defaultConfigPath = "configs/config.yaml"
 )
 
-// CSharpInput define la estructura para enviar datos a la aplicación C#
-type CSharpInput struct {
-	Mode     string `json:"mode"`
-	User     string `json:"user"`
-	Password string `json:"password"`
-	Host     string `json:"host"`
-	Query    string `json:"query"`
-	B1       string `json:"b1"`
-	B2       string `json:"b2"`
-	B3       string `json:"b3"`
-}
+// Códigos de salida de los comandos
+const (
+	exitOK        = 0   // Sin errores
+	exitError     = 1   // Error general
+	exitProcess   = 2   // El proceso C# o la consulta terminaron con error
+	exitIntegrity = 3   // Respuesta inválida o checksum incorrecto
+	exitTimeout   = 4   // Se superó database.connection_timeout
+	exitProtocol  = 5   // Versión de protocolo incompatible con survalentDB.exe
+	exitInvalid   = 6   // validate o xdf-validate encontraron errores
+	exitCanceled  = 130 // Cancelado por el usuario (Ctrl-C)
+)
 
 var (
 	// Flags globales
-	configFile string
-	user       string
-	password   string
-	host       string
-	path       string
-	aor        string
+	configFile   string
+	user         string
+	password     string
+	host         string
+	path         string
+	aor          string
+	stream       bool
+	schema       bool
+	format       string
+	diffFormat   string
+	sheet        string
+	allSheets    bool
+	delimiter    string
+	encoding     string
+	force        bool
+	profile      string
+	cellRange    string
+	fillMerged   bool
+	evalFormulas bool
+	jsonOutput   bool
+	outputFile   string
+	baselinePath string
+	schemaPath   string
+	strict       bool
 )
 
 func main() {
@@ -78,12 +94,19 @@ desde archivos CSV o Excel.`,
 		Short: "Busca una estación por nombre y retorna sus señales",
 		Long: `Busca una estación en la base de datos SURVALENT y retorna
 todas las señales asociadas. Requiere especificar el path (--path)
-y el área de responsabilidad (--aor).`,
+y el área de responsabilidad (--aor).
+
+Los XML se generan desde el archivo guardado igual que en csv-xml: con
+errores de validación (validation.rules, colisiones IFS o esquema XSD) se
+escribe el reporte y no se generan XML, salvo con --force. El archivo de
+señales se guarda en ambos casos.`,
 		Args: cobra.NoArgs,
 		Run:  runStationSearch,
 	}
 	stationSearchCmd.Flags().StringVar(&path, "path", "", "Path del sistema (ej: B1/B2/B3)")
 	stationSearchCmd.Flags().StringVar(&aor, "aor", "", "Área de responsabilidad")
+	stationSearchCmd.Flags().StringVar(&format, "format", fileio.FormatCSV, formatFlagUsage())
+	stationSearchCmd.Flags().BoolVar(&force, "force", false, "Genera los XML aunque haya errores de validación (validation.rules o esquema XSD)")
 	if err := stationSearchCmd.MarkFlagRequired("path"); err != nil {
 		log.Fatalf("[ERROR] Error marcando flag 'path' como requerido: %v", err)
 	}
@@ -94,19 +117,53 @@ y el área de responsabilidad (--aor).`,
 	// Comando: csv-xml (ahora soporta CSV y Excel)
 	csvXmlCmd := &cobra.Command{
 		Use:   "csv-xml",
-		Short: "Genera archivos XML desde CSV o Excel",
-		Long: `Genera archivos XML IFS e IMM a partir de archivos CSV o Excel.
+		Short: "Genera archivos XML desde CSV, Excel, ODS, JSON o YAML",
+		Long: `Genera archivos XML IFS e IMM a partir de archivos CSV, Excel, ODS o
+listas de señales JSON/YAML.
 		
 Formatos soportados:
   - CSV (.csv)
   - Excel (.xlsx, .xls)
+  - OpenDocument (.ods)
+  - JSON (.json) y YAML (.yaml, .yml): lista de objetos, una señal por objeto
   
-El archivo debe contener las columnas requeridas según la configuración.`,
+El archivo debe contener las columnas requeridas según la configuración;
+con --profile las cabeceras de planillas de terceros se traducen primero
+a las columnas canónicas (ver profiles). En CSV el delimitador y la codificación se detectan automáticamente
+(--delimiter y --encoding fuerzan otro valor). En Excel y ODS se procesa la
+primera hoja, la indicada con --sheet o, con --all-sheets, cada hoja
+seleccionada por files.sheets; --range limita la lectura a una tabla de
+Excel o un nombre definido. Con --fill-merged las celdas combinadas toman
+el valor del rango en todas sus filas y con --eval-formulas las fórmulas se
+recalculan en lugar de usar el resultado guardado en el libro. La fila de
+cabecera se busca entre las primeras files.header_search_rows filas (se
+omiten títulos y logos) y las filas vacías o de totales al final se ignoran.
+
+Las filas se validan con validation.rules; si hay errores se escribe un
+reporte y no se generan XML, salvo con --force. Cada XDF se valida además
+contra el esquema XSD (xml.schema o el esquema incluido) antes de escribirlo;
+un archivo que no lo cumple no se escribe, salvo con --force.
+
+Con --baseline (directorio con los XDF de la entrega anterior, o un XDF)
+cada archivo contiene solo los elementos nuevos o modificados y los
+eliminados se escriben en *_IFS_delete.xml / *_IMM_delete.xml, incluidos
+los de las estaciones que ya no están en la entrada.`,
 		Args: cobra.NoArgs,
 		Run:  runCSVToXML,
 	}
-	csvXmlCmd.Flags().StringVar(&path, "path", "", "Ruta del archivo CSV/Excel")
+	csvXmlCmd.Flags().StringVar(&path, "path", "", "Ruta del archivo CSV/Excel/ODS/JSON/YAML")
 	csvXmlCmd.Flags().StringVar(&aor, "aor", "", "Área de responsabilidad")
+	csvXmlCmd.Flags().StringVar(&sheet, "sheet", "", "Hoja Excel/ODS a procesar (nombre o índice desde 1)")
+	csvXmlCmd.Flags().BoolVar(&allSheets, "all-sheets", false, "Procesa cada hoja Excel/ODS como un dataset (ver files.sheets)")
+	csvXmlCmd.Flags().StringVar(&delimiter, "delimiter", "auto", "Delimitador CSV: auto, ',', ';', tab o '|'")
+	csvXmlCmd.Flags().StringVar(&encoding, "encoding", "auto", "Codificación CSV: auto, utf-8, utf-16le, utf-16be, latin1 o windows-1252")
+	csvXmlCmd.Flags().BoolVar(&force, "force", false, "Genera los XML aunque haya errores de validación (validation.rules o esquema XSD)")
+	csvXmlCmd.Flags().StringVar(&cellRange, "range", "", "Tabla de Excel, nombre definido o rango A1:B2 a procesar (.xlsx)")
+	csvXmlCmd.Flags().BoolVar(&fillMerged, "fill-merged", false, "Copia el valor de las celdas combinadas de Excel en todo el rango")
+	csvXmlCmd.Flags().BoolVar(&evalFormulas, "eval-formulas", false, "Recalcula las fórmulas de Excel en lugar de usar el resultado guardado")
+	csvXmlCmd.Flags().StringVar(&profile, "profile", "", "Perfil de columnas (profiles) para planillas con otras cabeceras")
+	csvXmlCmd.Flags().StringVar(&baselinePath, "baseline", "", "Directorio o XDF de la entrega anterior: genera solo los cambios")
+	csvXmlCmd.MarkFlagsMutuallyExclusive("sheet", "all-sheets")
 	if err := csvXmlCmd.MarkFlagRequired("path"); err != nil {
 		log.Fatalf("[ERROR] Error marcando flag 'path' como requerido: %v", err)
 	}
@@ -119,10 +176,103 @@ El archivo debe contener las columnas requeridas según la configuración.`,
 		Use:   "direct-query [SQL query]",
 		Short: "Ejecuta una query SQL directamente en la base de datos",
 		Long: `Ejecuta una consulta SQL directa en la base de datos SURVALENT
-y guarda los resultados en un archivo CSV.`,
+y guarda los resultados en un archivo (CSV por defecto, ver --format).`,
 		Args: cobra.ExactArgs(1),
 		Run:  runDirectQuery,
 	}
+	directQueryCmd.Flags().BoolVar(&stream, "stream", false, "Escribe las filas a medida que llegan (memoria constante)")
+	directQueryCmd.Flags().BoolVar(&schema, "schema", false, "Escribe un archivo .schema.json con las columnas")
+	directQueryCmd.Flags().StringVar(&format, "format", fileio.FormatCSV, formatFlagUsage())
+
+	// Comando: validate
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Revisa un archivo de entrada sin generar XML",
+		Long: `Revisa un archivo de entrada con las mismas reglas que csv-xml sin
+escribir nada en el directorio de salida:
+
+  - Columnas requeridas (validation.required_columns)
+  - Reglas por columna (validation.rules)
+  - Plantillas de ELEMENT no encontradas
+  - DASIP sin mapeo (se usaría default_path)
+  - Direcciones fuera de 0-255
+  - Nombres o direcciones IFS repetidos en el mismo IFS parent path (igual que csv-xml)
+
+--profile traduce las cabeceras igual que en csv-xml. Termina con código 6 si hay errores (o advertencias con --strict).`,
+		Args: cobra.NoArgs,
+		Run:  runValidate,
+	}
+	validateCmd.Flags().StringVar(&path, "path", "", "Ruta del archivo a revisar")
+	validateCmd.Flags().StringVar(&sheet, "sheet", "", "Hoja Excel/ODS a revisar (nombre o índice desde 1)")
+	validateCmd.Flags().StringVar(&delimiter, "delimiter", "auto", "Delimitador CSV: auto, ',', ';', tab o '|'")
+	validateCmd.Flags().StringVar(&encoding, "encoding", "auto", "Codificación CSV: auto, utf-8, utf-16le, utf-16be, latin1 o windows-1252")
+	validateCmd.Flags().StringVar(&cellRange, "range", "", "Tabla de Excel, nombre definido o rango A1:B2 a revisar (.xlsx)")
+	validateCmd.Flags().BoolVar(&fillMerged, "fill-merged", false, "Copia el valor de las celdas combinadas de Excel en todo el rango")
+	validateCmd.Flags().BoolVar(&evalFormulas, "eval-formulas", false, "Recalcula las fórmulas de Excel en lugar de usar el resultado guardado")
+	validateCmd.Flags().StringVar(&profile, "profile", "", "Perfil de columnas (profiles) para planillas con otras cabeceras")
+	validateCmd.Flags().BoolVar(&jsonOutput, "json", false, "Imprime el reporte en JSON")
+	validateCmd.Flags().BoolVar(&strict, "strict", false, "Las advertencias también terminan con error")
+	if err := validateCmd.MarkFlagRequired("path"); err != nil {
+		log.Fatalf("[ERROR] Error marcando flag 'path' como requerido: %v", err)
+	}
+
+	// Comando: xml-csv
+	xmlCsvCmd := &cobra.Command{
+		Use:   "xml-csv",
+		Short: "Reconstruye la planilla de señales desde archivos XML IFS/IMM",
+		Long: `Convierte archivos XDF generados (IFS y su IMM) de vuelta en una planilla
+de señales que csv-xml puede usar para regenerarlos.
+
+--path acepta un archivo IFS o IMM, o un directorio con varios pares
+(se asocian por output.suffixes). Las columnas ELEMENT, INFO, B1..B3, BAY y
+las direcciones se recuperan invirtiendo las reglas de naming; el DASIP se
+obtiene de dasip_config.yaml y el AOR del archivo IMM (o de --aor si no hay
+elemento IMM). Se escribe <prefijo>_senales.csv (o .xlsx con --format) en
+el directorio de salida.`,
+		Args: cobra.NoArgs,
+		Run:  runXMLToCSV,
+	}
+	xmlCsvCmd.Flags().StringVar(&path, "path", "", "Archivo IFS/IMM o directorio con archivos XML")
+	xmlCsvCmd.Flags().StringVar(&aor, "aor", "", "AOR de las filas sin elemento IMM")
+	xmlCsvCmd.Flags().StringVar(&format, "format", fileio.FormatCSV, "Formato de salida: csv o xlsx")
+	if err := xmlCsvCmd.MarkFlagRequired("path"); err != nil {
+		log.Fatalf("[ERROR] Error marcando flag 'path' como requerido: %v", err)
+	}
+
+	// Comando: xdf-diff
+	xdfDiffCmd := &cobra.Command{
+		Use:   "xdf-diff [anterior.xml] [nuevo.xml]",
+		Short: "Compara dos archivos XDF y reporta los elementos que cambiaron",
+		Long: `Compara dos archivos XDF (IFS o IMM) elemento por elemento. Cada elemento se
+identifica por el Path de su Parent, su tipo y su Name; el orden de los
+elementos y el formato del archivo no se consideran. Se reportan los
+elementos agregados, eliminados y modificados con sus atributos distintos
+(incluidos los de elementos hijos como AnalogValue o Terminal).
+
+El reporte se imprime en texto, JSON o HTML (--format); --output lo escribe
+en un archivo.`,
+		Args: cobra.ExactArgs(2),
+		Run:  runXDFDiff,
+	}
+	xdfDiffCmd.Flags().StringVar(&diffFormat, "format", "text", "Formato del reporte: text, json o html")
+	xdfDiffCmd.Flags().StringVar(&outputFile, "output", "", "Archivo donde escribir el reporte (por defecto la salida estándar)")
+
+	// Comando: xdf-validate
+	xdfValidateCmd := &cobra.Command{
+		Use:   "xdf-validate [archivo.xml...]",
+		Short: "Valida archivos XDF contra el esquema XSD",
+		Long: `Valida archivos XDF (IFS o IMM) contra el mismo esquema con el que csv-xml
+revisa cada archivo antes de escribirlo: atributos requeridos, PathB y Path
+no vacíos, direcciones 0-255, elementos permitidos en cada Parent, etc.
+
+Se usa xml.schema de la configuración, o --schema para otro archivo XSD; sin
+ninguno se usa el esquema XDF incluido. Termina con código 6 si algún archivo
+no cumple el esquema.`,
+		Args: cobra.MinimumNArgs(1),
+		Run:  runXDFValidate,
+	}
+	xdfValidateCmd.Flags().StringVar(&schemaPath, "schema", "", "Esquema XSD (por defecto xml.schema o el esquema incluido)")
+	xdfValidateCmd.Flags().BoolVar(&jsonOutput, "json", false, "Imprime el reporte en JSON")
 
 	// Comando: version
 	versionCmd := &cobra.Command{
@@ -139,7 +289,7 @@ y guarda los resultados en un archivo CSV.`,
 	}
 
 	// Agregar comandos
-	rootCmd.AddCommand(stationSearchCmd, directQueryCmd, csvXmlCmd, versionCmd)
+	rootCmd.AddCommand(stationSearchCmd, directQueryCmd, csvXmlCmd, xmlCsvCmd, validateCmd, xdfDiffCmd, xdfValidateCmd, versionCmd)
 
 	// Ejecutar
 	if err := rootCmd.Execute(); err != nil {
@@ -171,7 +321,11 @@ func initializeApp(cmd *cobra.Command, args []string) {
 		log.Printf("[WARN] Error cargando plantillas: %v", err)
 	}
 
-	// Asegurar que el directorio de salida exista
+	// Asegurar que el directorio de salida exista (validate y xdf-validate no
+	// escriben archivos)
+	if cmd.Name() == "validate" || cmd.Name() == "xdf-validate" {
+		return
+	}
 	if err := config.EnsureOutputDir(); err != nil {
 		log.Printf("[WARN] Error creando directorio de salida: %v", err)
 	}
@@ -179,261 +333,576 @@ func initializeApp(cmd *cobra.Command, args []string) {
 
 // runStationSearch ejecuta la búsqueda de estación
 func runStationSearch(cmd *cobra.Command, args []string) {
-	executeCommand("station_search", "", path, aor)
+	exitWith(stationSearch())
+}
+
+// stationSearch busca la estación, guarda sus señales y genera los XML.
+// Retorna el código de salida para que los defer (cierre del backend y
+// cancelación del contexto) se ejecuten antes de terminar
+func stationSearch() int {
+	checkOutputFormat()
+
+	empresa, region, b1, b2, b3, err := parsePath(path)
+	if err != nil {
+		log.Printf("[WARN] Error parseando path: %v", err)
+	}
+
+	backend := openBackend()
+	defer backend.Close()
+
+	ctx, cancel := databaseContext()
+	defer cancel()
+
+	result, err := backend.StationSearch(ctx, database.Station{B1: b1, B2: b2, B3: b3})
+	if err != nil {
+		return databaseExitCode("Error en búsqueda de estación", err)
+	}
+
+	timestamp := time.Now().Format(config.Global.Output.TimestampFormat)
+	filename := outputFileName(fmt.Sprintf("%s_%s", timestamp, b3), format)
+
+	if err := saveResult(result, filename, format, newStationSearchSink(empresa, region, aor)); err != nil {
+		log.Printf("[ERROR] Error guardando búsqueda: %v", err)
+		return exitError
+	}
+	log.Printf("[OK] Datos guardados en: %s", filename)
+
+	// Los XML se generan leyendo el archivo guardado
+	if !config.IsFormatSupported(format) {
+		log.Printf("[WARN] Generación de XML omitida: el formato '%s' no se admite como entrada (use csv o xlsx)", format)
+		return exitOK
+	}
+
+	// Generar XMLs automáticamente
+	log.Println("[INFO] Generando archivos XML...")
+	if err := xmlcreator.CreateXMLFromFile(filename, xmlcreator.Options{Force: force}); err != nil {
+		log.Printf("[ERROR] Error generando XML: %v", err)
+		return exitError
+	}
+	return exitOK
 }
 
 // runDirectQuery ejecuta una query directa
 func runDirectQuery(cmd *cobra.Command, args []string) {
-	query := args[0]
-	executeCommand("direct_query", query, "", "")
+	exitWith(directQuery(args[0]))
 }
 
-// runCSVToXML ejecuta la conversión de CSV/Excel a XML
-func runCSVToXML(cmd *cobra.Command, args []string) {
-	// Verificar que el archivo exista
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		log.Fatalf("[ERROR] El archivo '%s' no existe", path)
+// directQuery ejecuta la query y guarda el resultado. Como stationSearch,
+// retorna el código de salida en lugar de terminar el proceso
+func directQuery(query string) int {
+	checkOutputFormat()
+
+	backend := openBackend()
+	defer backend.Close()
+
+	formatter, err := database.NewValueFormatter(config.Global.Output.Values)
+	if err != nil {
+		log.Printf("[ERROR] Configuración output.values inválida: %v", err)
+		return exitError
 	}
 
-	// Verificar formato soportado
-	ext := strings.ToLower(filepath.Ext(path))
-	ext = strings.TrimPrefix(ext, ".")
+	ctx, cancel := databaseContext()
+	defer cancel()
 
-	if !config.IsFormatSupported(ext) {
-		log.Fatalf("[ERROR] Formato '%s' no soportado. Formatos válidos: %v",
-			ext, config.Global.Files.SupportedInputFormats)
+	timestamp := time.Now().Format(config.Global.Output.TimestampFormat)
+	filename := outputFileName(timestamp+"_direct_query", format)
+
+	sink := newDirectQuerySink(formatter)
+	var count int64
+	if stream {
+		count, err = streamResult(ctx, backend, query, filename, format, sink)
+		if err != nil {
+			return databaseExitCode("Error ejecutando query", err)
+		}
+	} else {
+		result, err := backend.DirectQuery(ctx, query)
+		if err != nil {
+			return databaseExitCode("Error ejecutando query", err)
+		}
+
+		if err := saveResult(result, filename, format, sink); err != nil {
+			log.Printf("[ERROR] Error guardando query: %v", err)
+			return exitError
+		}
+		count = int64(len(result.Rows))
+	}
+	log.Printf("[OK] Resultados guardados en: %s (%d filas)", filename, count)
+
+	if schema || config.Global.Output.Values.SchemaSidecar {
+		schemaPath := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".schema.json"
+		if err := formatter.WriteSchema(schemaPath, sink.columns, count); err != nil {
+			log.Printf("[ERROR] %v", err)
+			return exitError
+		}
+		log.Printf("[OK] Esquema guardado en: %s", schemaPath)
 	}
+	return exitOK
+}
 
+// runCSVToXML ejecuta la conversión de CSV/Excel/ODS/JSON/YAML a XML
+func runCSVToXML(cmd *cobra.Command, args []string) {
+	ext := checkInputFile()
 	log.Printf("[INFO] Procesando archivo: %s (formato: %s)", path, strings.ToUpper(ext))
 
+	hasSheets := ext == "xlsx" || ext == "xls" || ext == "ods"
+	if (sheet != "" || allSheets) && !hasSheets {
+		log.Printf("[WARN] --sheet/--all-sheets se ignoran para archivos %s", strings.ToUpper(ext))
+	}
+
+	opts := inputReadOptions(ext)
+	if allSheets && opts.Range != "" {
+		log.Fatalf("[ERROR] --range y --all-sheets no se pueden combinar")
+	}
+
+	xmlOpts := xmlcreator.Options{Read: opts, Force: force}
+	if baselinePath != "" {
+		baseline, err := xmlcreator.LoadBaseline(baselinePath)
+		if err != nil {
+			log.Fatalf("[ERROR] %v", err)
+		}
+		xmlOpts.Baseline = baseline
+		log.Printf("[INFO] Línea base: %s (se generan solo los cambios)", baselinePath)
+	}
+
+	// Procesar cada hoja seleccionada como un dataset
+	if allSheets && hasSheets {
+		sheets, err := fileio.ListSheets(path)
+		if err != nil {
+			log.Fatalf("[ERROR] %v", err)
+		}
+
+		selected := fileio.FilterSheets(sheets, config.Global.Files.Sheets.Include, config.Global.Files.Sheets.Exclude)
+		if len(selected) == 0 {
+			log.Fatalf("[ERROR] Ninguna hoja coincide con files.sheets (hojas: %s)", strings.Join(sheets, ", "))
+		}
+		log.Printf("[INFO] Hojas seleccionadas: %d de %d (%s)", len(selected), len(sheets), strings.Join(selected, ", "))
+
+		if err := xmlcreator.CreateXMLFromSheets(path, selected, xmlOpts); err != nil {
+			log.Fatalf("[ERROR] Error generando XML: %v", err)
+		}
+
+		log.Println("[OK] Proceso completado exitosamente")
+		return
+	}
+
 	// Crear XMLs
-	if err := xmlcreator.CreateXMLFromFile(path); err != nil {
+	if err := xmlcreator.CreateXMLFromFile(path, xmlOpts); err != nil {
 		log.Fatalf("[ERROR] Error generando XML: %v", err)
 	}
 
 	log.Println("[OK] Proceso completado exitosamente")
 }
 
-// executeCommand ejecuta un comando hacia la base de datos C#
-func executeCommand(mode, query, path, aor string) {
-	empresa, region, b1, b2, b3, err := parsePath(path)
-	if err != nil && mode != "direct_query" {
-		log.Printf("[WARN] Error parseando path: %v", err)
+// runValidate revisa un archivo de entrada sin generar XML ni escribir en el
+// directorio de salida
+func runValidate(cmd *cobra.Command, args []string) {
+	ext := checkInputFile()
+	if sheet != "" && ext != "xlsx" && ext != "xls" && ext != "ods" {
+		log.Printf("[WARN] --sheet se ignora para archivos %s", strings.ToUpper(ext))
 	}
 
-	// Solicitar credenciales si no están disponibles
-	if host == "" {
-		host = readInput("Host: ")
+	report, err := xmlcreator.LintFile(path, inputReadOptions(ext))
+	if err != nil {
+		log.Fatalf("[ERROR] %v", err)
 	}
 
-	if user == "" {
-		user = readInput("Usuario: ")
+	if jsonOutput {
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			log.Fatalf("[ERROR] %v", err)
+		}
+	} else {
+		report.Print(os.Stdout)
 	}
 
-	if password == "" {
-		fmt.Print("Contraseña: ")
-		bytePassword, err := term.ReadPassword(int(os.Stdin.Fd()))
-		if err != nil {
-			log.Fatalf("[ERROR] Error leyendo contraseña: %v", err)
-		}
-		fmt.Println()
-		password = string(bytePassword)
+	if report.Errors > 0 || (strict && report.Warnings > 0) {
+		os.Exit(exitInvalid)
 	}
+}
 
-	// Preparar datos de entrada
-	inputData := CSharpInput{
-		Mode:     mode,
-		User:     user,
-		Password: password,
-		Host:     host,
-		Query:    query,
-		B1:       b1,
-		B2:       b2,
-		B3:       b3,
+// runXMLToCSV reconstruye la planilla de señales de cada par IFS/IMM
+func runXMLToCSV(cmd *cobra.Command, args []string) {
+	format = strings.ToLower(format)
+	if format != fileio.FormatCSV && format != fileio.FormatXLSX {
+		log.Fatalf("[ERROR] Formato de salida '%s' no soportado por xml-csv (use csv o xlsx)", format)
 	}
 
-	inputBytes, _ := json.Marshal(inputData)
-	inputJSON := string(inputBytes)
+	pairs, err := xmlcreator.FindXDFPairs(path)
+	if err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
 
-	// Ejecutar proceso C#
-	csharpExe := config.Global.Database.CSharpExecutable
-	cmd := exec.Command(csharpExe)
+	skipped := 0
+	for _, pair := range pairs {
+		list, err := xmlcreator.ImportXDF(pair, xmlcreator.ImportOptions{AOR: aor})
+		if err != nil {
+			log.Fatalf("[ERROR] %v", err)
+		}
+		skipped += list.Skipped
 
-	stdoutPipe, _ := cmd.StdoutPipe()
-	stderrPipe, _ := cmd.StderrPipe()
-	stdinPipe, _ := cmd.StdinPipe()
+		filename := outputFileName(pair.Base+"_senales", format)
+		if err := list.Write(format, filename); err != nil {
+			log.Fatalf("[ERROR] Error escribiendo '%s': %v", filename, err)
+		}
+		log.Printf("[OK] Planilla generada: %s (%d filas)", filename, len(list.Rows))
+	}
 
-	if err := cmd.Start(); err != nil {
-		log.Fatalf("[ERROR] Error iniciando proceso: %v", err)
+	if skipped > 0 {
+		log.Printf("[WARN] %d puntos IFS no se pudieron reconstruir (ver advertencias)", skipped)
 	}
+	log.Println("[OK] Proceso completado exitosamente")
+}
 
-	// Enviar entrada
-	go func() {
-		defer stdinPipe.Close()
-		fmt.Fprintln(stdinPipe, inputJSON)
-	}()
+// runXDFDiff compara dos archivos XDF y escribe el reporte
+func runXDFDiff(cmd *cobra.Command, args []string) {
+	diffFormat = strings.ToLower(diffFormat)
+	if diffFormat != "text" && diffFormat != "json" && diffFormat != "html" {
+		log.Fatalf("[ERROR] Formato de reporte '%s' no soportado (use text, json o html)", diffFormat)
+	}
 
-	// Leer salida
-	var wg sync.WaitGroup
-	var outBuf, errBuf bytes.Buffer
-	wg.Add(2)
+	diff, err := xmlcreator.DiffXDF(args[0], args[1])
+	if err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
 
-	go func() {
-		defer wg.Done()
-		if _, err := io.Copy(&outBuf, stdoutPipe); err != nil {
-			log.Printf("[ERROR] Error leyendo stdout: %v", err)
+	out := os.Stdout
+	if outputFile != "" {
+		out, err = os.Create(outputFile)
+		if err != nil {
+			log.Fatalf("[ERROR] Error creando '%s': %v", outputFile, err)
 		}
-	}()
+	}
 
-	go func() {
-		defer wg.Done()
-		if _, err := io.Copy(&errBuf, stderrPipe); err != nil {
-			log.Printf("[ERROR] Error leyendo stderr: %v", err)
+	if err := diff.WriteReport(out, diffFormat); err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
+	if outputFile != "" {
+		if err := out.Close(); err != nil {
+			log.Fatalf("[ERROR] Error escribiendo '%s': %v", outputFile, err)
 		}
-	}()
+		log.Printf("[OK] Reporte escrito en %s (%d agregados, %d eliminados, %d modificados)", outputFile, diff.Added, diff.Removed, diff.Modified)
+	}
+}
 
-	wg.Wait()
+// runXDFValidate valida archivos XDF contra el esquema
+func runXDFValidate(cmd *cobra.Command, args []string) {
+	if schemaPath == "" {
+		schemaPath = config.Global.XML.Schema
+	}
+	schema, err := xmlcreator.LoadSchema(schemaPath)
+	if err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
 
-	if err := cmd.Wait(); err != nil {
-		log.Printf("[ERROR] Error en proceso: %v", err)
-		if errBuf.Len() > 0 {
-			log.Printf("[STDERR] %s", errBuf.String())
+	report := schema.ValidateFiles(args)
+	if jsonOutput {
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			log.Fatalf("[ERROR] %v", err)
 		}
-		return
+	} else {
+		report.Print(os.Stdout)
 	}
 
-	if errBuf.Len() > 0 {
-		fmt.Printf("[WARN] STDERR:\n%s\n", errBuf.String())
+	if report.Invalid > 0 {
+		os.Exit(exitInvalid)
 	}
+}
 
-	// Procesar salida
-	output := outBuf.Bytes()
-	if !gjson.ValidBytes(output) {
-		log.Println("[WARN] La salida no es JSON válido")
-		fmt.Printf("[OUTPUT]\n%s\n", outBuf.String())
-		return
+// checkInputFile verifica que --path exista y tenga un formato de entrada
+// soportado. Retorna la extensión en minúsculas sin punto
+func checkInputFile() string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		log.Fatalf("[ERROR] El archivo '%s' no existe", path)
 	}
 
-	// Verificar integridad
-	payloadJSON := gjson.GetBytes(output, "payload").Raw
-	receivedChecksum := gjson.GetBytes(output, "checksum").String()
-
-	if payloadJSON == "" || receivedChecksum == "" {
-		log.Fatal("[ERROR] Respuesta JSON inválida (falta payload o checksum)")
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if !config.IsFormatSupported(ext) {
+		log.Fatalf("[ERROR] Formato '%s' no soportado. Formatos válidos: %v",
+			ext, config.Global.Files.SupportedInputFormats)
 	}
+	return ext
+}
 
-	hasher := sha256.New()
-	hasher.Write([]byte(payloadJSON))
-	calculatedChecksum := hex.EncodeToString(hasher.Sum(nil))
+// inputReadOptions construye las opciones de lectura desde --sheet,
+// --delimiter, --encoding, --range, --fill-merged, --eval-formulas y --profile
+func inputReadOptions(ext string) fileio.ReadOptions {
+	opts := fileio.ReadOptions{Sheet: sheet}
+
+	// Tablas y nombres definidos solo existen en libros Excel
+	if cellRange != "" {
+		if ext == "xlsx" || ext == "xls" {
+			opts.Range = cellRange
+		} else {
+			log.Printf("[WARN] --range se ignora para archivos %s", strings.ToUpper(ext))
+		}
+	}
+	if fillMerged || evalFormulas {
+		if ext == "xlsx" || ext == "xls" {
+			opts.FillMerged, opts.EvalFormulas = fillMerged, evalFormulas
+		} else {
+			log.Printf("[WARN] --fill-merged/--eval-formulas se ignoran para archivos %s", strings.ToUpper(ext))
+		}
+	}
 
-	if calculatedChecksum != receivedChecksum {
-		log.Fatal("[ERROR] ERROR DE INTEGRIDAD! Los datos pueden estar corruptos")
+	// Dialecto CSV forzado por el usuario
+	var err error
+	if opts.Delimiter, err = fileio.ParseDelimiter(delimiter); err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
+	if opts.Encoding, err = fileio.ParseEncoding(encoding); err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
+	if ext != "csv" && (opts.Delimiter != 0 || opts.Encoding != "") {
+		log.Printf("[WARN] --delimiter/--encoding se ignoran para archivos %s", strings.ToUpper(ext))
 	}
 
-	log.Println("[OK] Verificación de integridad exitosa")
+	// Perfil de columnas para planillas de terceros
+	if profile != "" {
+		p, ok := config.Global.Profiles[profile]
+		if !ok {
+			log.Fatalf("[ERROR] Perfil '%s' no definido en profiles (perfiles: %s)", profile, strings.Join(profileNames(), ", "))
+		}
+		opts.Mapping = &fileio.ColumnMapping{
+			Name:       profile,
+			Aliases:    p.Aliases,
+			Constants:  p.Constants,
+			IgnoreCase: p.IgnoreCase,
+		}
+	}
+	return opts
+}
 
-	// Procesar según el modo
-	switch mode {
-	case "direct_query":
-		timestamp := time.Now().Format(config.Global.Output.TimestampFormat)
-		filename := fmt.Sprintf("%s_direct_query%s", timestamp, config.Global.Output.Suffixes["csv"])
-		filename = config.GetOutputPath(filename)
+// profileNames retorna los perfiles de columnas configurados en orden
+func profileNames() []string {
+	names := make([]string, 0, len(config.Global.Profiles))
+	for name := range config.Global.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-		if err := saveDirectQueryToCSV(payloadJSON, filename); err != nil {
-			log.Fatalf("[ERROR] Error guardando query: %v", err)
+// openBackend crea el backend de base de datos configurado, solicitando
+// las credenciales si el backend las requiere
+func openBackend() database.Backend {
+	if database.NeedsCredentials(config.Global.Database) {
+		if host == "" {
+			host = readInput("Host: ")
 		}
-		log.Printf("[OK] Resultados guardados en: %s", filename)
-
-	case "station_search":
-		timestamp := time.Now().Format(config.Global.Output.TimestampFormat)
-		filename := fmt.Sprintf("%s_%s%s", timestamp, b3, config.Global.Output.Suffixes["csv"])
-		filename = config.GetOutputPath(filename)
 
-		if err := saveStationSearchToCSV(payloadJSON, filename, empresa, region, aor); err != nil {
-			log.Fatalf("[ERROR] Error guardando búsqueda: %v", err)
+		if user == "" {
+			user = readInput("Usuario: ")
 		}
-		log.Printf("[OK] Datos guardados en: %s", filename)
 
-		// Generar XMLs automáticamente
-		log.Println("[INFO] Generando archivos XML...")
-		if err := xmlcreator.CreateXMLFromFile(filename); err != nil {
-			log.Fatalf("[ERROR] Error generando XML: %v", err)
+		if password == "" {
+			fmt.Print("Contraseña: ")
+			bytePassword, err := term.ReadPassword(int(os.Stdin.Fd()))
+			if err != nil {
+				log.Fatalf("[ERROR] Error leyendo contraseña: %v", err)
+			}
+			fmt.Println()
+			password = string(bytePassword)
 		}
 	}
-}
 
-// saveDirectQueryToCSV guarda los resultados de una query directa
-func saveDirectQueryToCSV(payloadJSON, filePath string) error {
-	writer, err := fileio.NewCSVWriter(filePath)
+	backend, err := database.New(config.Global.Database, database.Credentials{
+		Host:     host,
+		User:     user,
+		Password: password,
+	})
 	if err != nil {
-		return err
+		log.Fatalf("[ERROR] Error inicializando base de datos: %v", err)
 	}
-	defer writer.Close()
 
-	// Obtener columnas
-	columnsResult := gjson.Get(payloadJSON, "columns.#.name")
-	var headers []string
-	for _, name := range columnsResult.Array() {
-		headers = append(headers, name.String())
+	log.Printf("[INFO] Backend de base de datos: %s", config.Global.Database.Backend)
+	return backend
+}
+
+// databaseContext crea el contexto de las operaciones de base de datos. Se
+// cancela con Ctrl-C/SIGTERM y al superar database.connection_timeout
+func databaseContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	timeout := time.Duration(config.Global.Database.ConnectionTimeout) * time.Second
+	if timeout <= 0 {
+		return ctx, stop
 	}
 
-	if err := writer.WriteRow(headers); err != nil {
-		return err
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
 	}
+}
 
-	// Escribir datos
-	dataResult := gjson.Get(payloadJSON, "data")
-	dataResult.ForEach(func(key, row gjson.Result) bool {
-		var record []string
-		for _, header := range headers {
-			value := row.Get(header)
-			record = append(record, value.String())
-		}
-		if err := writer.WriteRow(record); err != nil {
-			log.Printf("[ERROR] Error escribiendo fila: %v", err)
+// databaseExitCode registra un error de base de datos y retorna el código de
+// salida correspondiente a su tipo
+func databaseExitCode(msg string, err error) int {
+	code := exitError
+	switch {
+	case errors.Is(err, database.ErrTimeout):
+		code = exitTimeout
+		log.Printf("[ERROR] %s (timeout %ds): %v", msg, config.Global.Database.ConnectionTimeout, err)
+	case errors.Is(err, database.ErrCanceled):
+		code = exitCanceled
+		log.Printf("[ERROR] %s: %v", msg, err)
+	case errors.Is(err, database.ErrProtocol):
+		code = exitProtocol
+		log.Printf("[ERROR] %s: %v", msg, err)
+	case errors.Is(err, database.ErrIntegrity):
+		code = exitIntegrity
+		log.Printf("[ERROR] %s: %v", msg, err)
+	case errors.Is(err, database.ErrProcess):
+		code = exitProcess
+		log.Printf("[ERROR] %s: %v", msg, err)
+	default:
+		log.Printf("[ERROR] %s: %v", msg, err)
+	}
+	return code
+}
+
+// exitWith termina el proceso con el código indicado si no es exitOK
+func exitWith(code int) {
+	if code != exitOK {
+		os.Exit(code)
+	}
+}
+
+// formatFlagUsage retorna la ayuda del flag --format
+func formatFlagUsage() string {
+	return fmt.Sprintf("Formato de salida (%s)", strings.Join(fileio.TableFormats, ", "))
+}
+
+// checkOutputFormat valida el flag --format antes de consultar la base de datos
+func checkOutputFormat() {
+	format = strings.ToLower(format)
+	if !fileio.IsTableFormat(format) {
+		log.Fatalf("[ERROR] Formato de salida '%s' no soportado. Formatos válidos: %v", format, fileio.TableFormats)
+	}
+}
+
+// tableSink escribe un resultado con un TableWriter a medida que llegan las
+// filas. Las columnas constantes (prefix) se agregan al inicio de cada fila
+type tableSink struct {
+	writer  fileio.TableWriter
+	format  func(column database.Column, value any) string
+	prefix  []string
+	values  []string
+	columns []database.Column
+	cells   []fileio.Cell
+}
+
+// newDirectQuerySink formatea los valores según el tipo de su columna
+func newDirectQuerySink(formatter *database.ValueFormatter) *tableSink {
+	return &tableSink{format: formatter.Format}
+}
+
+// newStationSearchSink agrega EMPRESA, REGION y AOR a cada fila, que luego
+// se usan para generar los XML
+func newStationSearchSink(empresa, region, aor string) *tableSink {
+	return &tableSink{
+		format: func(_ database.Column, value any) string { return database.FormatValue(value) },
+		prefix: []string{"EMPRESA", "REGION", "AOR"},
+		values: []string{empresa, region, aor},
+	}
+}
+
+func (s *tableSink) Begin(columns []database.Column) error {
+	header := make([]fileio.TableColumn, 0, len(s.prefix)+len(columns))
+	for _, name := range s.prefix {
+		header = append(header, fileio.TableColumn{Name: name})
+	}
+	for _, col := range columns {
+		header = append(header, fileio.TableColumn{Name: col.Name, Type: tableColumnType(col)})
+	}
+
+	s.columns = columns
+	s.cells = make([]fileio.Cell, len(header))
+	for i, value := range s.values {
+		s.cells[i] = fileio.Cell{Value: value}
+	}
+	return s.writer.WriteHeader(header)
+}
+
+func (s *tableSink) Row(values []any) error {
+	offset := len(s.prefix)
+	for i, value := range values {
+		s.cells[offset+i] = fileio.Cell{
+			Value: s.format(s.columns[i], value),
+			Null:  value == nil,
 		}
-		return true
-	})
+	}
+	return s.writer.WriteRow(s.cells)
+}
 
-	return nil
+// tableColumnType traduce el tipo de una columna al tipo de salida
+func tableColumnType(col database.Column) fileio.ColumnType {
+	switch database.KindOf(col.Type) {
+	case database.KindInteger:
+		return fileio.IntegerColumn
+	case database.KindFloat, database.KindDecimal:
+		return fileio.NumberColumn
+	default:
+		return fileio.TextColumn
+	}
 }
 
-// saveStationSearchToCSV guarda los resultados de búsqueda de estación
-func saveStationSearchToCSV(payloadJSON, filePath, empresa, region, aor string) error {
-	writer, err := fileio.NewCSVWriter(filePath)
-	if err != nil {
-		return err
+// outputFileName construye la ruta de salida con el sufijo del formato
+func outputFileName(base, format string) string {
+	suffix, ok := config.Global.Output.Suffixes[format]
+	if !ok {
+		suffix = "." + format
 	}
-	defer writer.Close()
+	return config.GetOutputPath(base + suffix)
+}
 
-	// Obtener columnas dinámicas
-	columnsResult := gjson.Get(payloadJSON, "columns.#.name")
-	var dynamicHeaders []string
-	for _, name := range columnsResult.Array() {
-		dynamicHeaders = append(dynamicHeaders, name.String())
+// saveResult escribe un resultado completo en el formato indicado
+func saveResult(result *database.Result, filePath, format string, sink *tableSink) error {
+	writer, err := fileio.NewTableWriter(format, filePath)
+	if err != nil {
+		return err
 	}
+	sink.writer = writer
 
-	// Cabeceras completas
-	headers := append([]string{"EMPRESA", "REGION", "AOR"}, dynamicHeaders...)
-	if err := writer.WriteRow(headers); err != nil {
+	if err := sink.Begin(result.Columns); err != nil {
+		writer.Close()
 		return err
 	}
 
 	// Escribir datos
-	dataResult := gjson.Get(payloadJSON, "data")
-	dataResult.ForEach(func(key, row gjson.Result) bool {
-		record := []string{empresa, region, aor}
-		for _, header := range dynamicHeaders {
-			value := row.Get(header)
-			record = append(record, value.String())
-		}
-		if err := writer.WriteRow(record); err != nil {
-			log.Printf("[ERROR] Error escribiendo fila: %v", err)
+	for _, row := range result.Rows {
+		if err := sink.Row(row); err != nil {
+			writer.Close()
+			return fmt.Errorf("error escribiendo fila: %w", err)
 		}
-		return true
-	})
+	}
+
+	return writer.Close()
+}
+
+// streamResult ejecuta una query en modo streaming escribiendo el archivo
+// fila a fila. El archivo se escribe como .partial y se renombra solo si la
+// verificación de integridad es exitosa
+func streamResult(ctx context.Context, backend database.Backend, query, filePath, format string, sink *tableSink) (int64, error) {
+	partialPath := filePath + ".partial"
+	writer, err := fileio.NewTableWriter(format, partialPath)
+	if err != nil {
+		return 0, err
+	}
+	sink.writer = writer
 
-	return nil
+	count, err := backend.StreamQuery(ctx, query, sink)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partialPath)
+		return count, err
+	}
+
+	if err := os.Rename(partialPath, filePath); err != nil {
+		return count, fmt.Errorf("error renombrando '%s': %w", partialPath, err)
+	}
+	return count, nil
 }
 
 // parsePath parsea un path en sus componentes
@@ -0,0 +1,45 @@
+-- Fixture SQLite para probar goScadaSur sin acceso a SURVALENT.
+-- Se ejecuta cada vez que se abre el backend "sqlite" (database.fixture_seed).
+
+CREATE TABLE IF NOT EXISTS signals (
+    PKEY     INTEGER PRIMARY KEY,
+    B1       TEXT NOT NULL,
+    B2       TEXT NOT NULL,
+    B3       TEXT NOT NULL,
+    MIEC104  INTEGER,
+    CIEC104  INTEGER,
+    TYPE     TEXT,
+    ELEMENT  TEXT,
+    INFO     TEXT,
+    SBO      INTEGER,
+    MLB      INTEGER,
+    MMB      INTEGER,
+    MHB      INTEGER,
+    CLB      INTEGER,
+    CMB      INTEGER,
+    CHB      INTEGER,
+    DASIP    TEXT
+);
+
+INSERT OR IGNORE INTO signals
+    (PKEY, B1, B2, B3, MIEC104, CIEC104, TYPE, ELEMENT, INFO, SBO, MLB, MMB, MHB, CLB, CMB, CHB, DASIP)
+VALUES
+    (10111027, 'M20117', 'LACEJA', 'TEST001', 1, NULL, 'MV', 'I_R', 'MvMoment', NULL, 1, 0, 0, NULL, NULL, NULL, '1'),
+    (10111028, 'M20117', 'LACEJA', 'TEST001', 2, NULL, 'MV', 'I_S', 'MvMoment', NULL, 2, 0, 0, NULL, NULL, NULL, '1'),
+    (10111029, 'M20117', 'LACEJA', 'TEST001', 3, NULL, 'MV', 'P', 'MvMoment', NULL, 3, 0, 0, NULL, NULL, NULL, '1'),
+    (10111030, 'M20117', 'LACEJA', 'TEST001', 4, 5, 'SP_SC', 'Reclos', 'Status', 1, 4, 0, 0, 5, 0, 0, '1');
+
+-- Mediciones con tipos variados para probar el formato de direct-query
+CREATE TABLE IF NOT EXISTS measurements (
+    PKEY      INTEGER NOT NULL,
+    VALUE     REAL,
+    QUALITY   DECIMAL(10,4),
+    UPDATED   DATETIME,
+    RAW       BLOB
+);
+
+INSERT INTO measurements (PKEY, VALUE, QUALITY, UPDATED, RAW)
+SELECT * FROM (
+    SELECT 10111027, 13.2000001, 1.5000, '2024-03-01 12:30:00', X'0A1B'
+    UNION ALL SELECT 10111028, NULL, NULL, NULL, NULL
+) WHERE NOT EXISTS (SELECT 1 FROM measurements);
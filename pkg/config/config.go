@@ -5,21 +5,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
+	"text/template"
 
 	"gopkg.in/yaml.v3"
 )
 
 // AppConfig representa la configuración completa de la aplicación
 type AppConfig struct {
-	App        AppInfo           `yaml:"app"`
-	Files      FilesConfig       `yaml:"files"`
-	XML        XMLConfig         `yaml:"xml"`
-	Logging    LoggingConfig     `yaml:"logging"`
-	Database   DatabaseConfig    `yaml:"database"`
-	Output     OutputConfig      `yaml:"output"`
-	Validation ValidationConfig  `yaml:"validation"`
-	Processing ProcessingConfig  `yaml:"processing"`
+	App        AppInfo                  `yaml:"app"`
+	Files      FilesConfig              `yaml:"files"`
+	XML        XMLConfig                `yaml:"xml"`
+	Logging    LoggingConfig            `yaml:"logging"`
+	Database   DatabaseConfig           `yaml:"database"`
+	Output     OutputConfig             `yaml:"output"`
+	Validation ValidationConfig         `yaml:"validation"`
+	Processing ProcessingConfig         `yaml:"processing"`
+	Naming     NamingConfig             `yaml:"naming"`
+	Profiles   map[string]ColumnProfile `yaml:"profiles"`
 }
 
 type AppInfo struct {
@@ -29,16 +34,26 @@ type AppInfo struct {
 }
 
 type FilesConfig struct {
-	Templates              string   `yaml:"templates"`
-	DasipMapping           string   `yaml:"dasip_mapping"`
-	OutputDir              string   `yaml:"output_dir"`
-	SupportedInputFormats  []string `yaml:"supported_input_formats"`
+	Templates             string       `yaml:"templates"`
+	DasipMapping          string       `yaml:"dasip_mapping"`
+	OutputDir             string       `yaml:"output_dir"`
+	SupportedInputFormats []string     `yaml:"supported_input_formats"`
+	Sheets                SheetsConfig `yaml:"sheets"`
+	HeaderSearchRows      int          `yaml:"header_search_rows"` // Filas revisadas al buscar la cabecera
+}
+
+// SheetsConfig selecciona las hojas Excel procesadas con --all-sheets.
+// Los patrones usan la sintaxis de filepath.Match sin distinguir mayúsculas
+type SheetsConfig struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
 }
 
 type XMLConfig struct {
 	Lang    string `yaml:"lang"`
 	Version string `yaml:"version"`
 	Indent  string `yaml:"indent"`
+	Schema  string `yaml:"schema"` // XSD de los XDF generados; vacío usa el esquema incluido
 }
 
 type LoggingConfig struct {
@@ -47,18 +62,66 @@ type LoggingConfig struct {
 }
 
 type DatabaseConfig struct {
-	ConnectionTimeout int    `yaml:"connection_timeout"`
-	CSharpExecutable  string `yaml:"csharp_executable"`
+	ConnectionTimeout  int    `yaml:"connection_timeout"`
+	Backend            string `yaml:"backend"`
+	CSharpExecutable   string `yaml:"csharp_executable"`
+	Driver             string `yaml:"driver"`
+	DSN                string `yaml:"dsn"`
+	StationSearchQuery string `yaml:"station_search_query"`
+	FixtureSeed        string `yaml:"fixture_seed"`
 }
 
 type OutputConfig struct {
 	TimestampFormat string            `yaml:"timestamp_format"`
 	Suffixes        map[string]string `yaml:"suffixes"`
+	Values          ValueFormatConfig `yaml:"values"`
+}
+
+// ValueFormatConfig define cómo se escriben los valores de direct-query según
+// el tipo de cada columna
+type ValueFormatConfig struct {
+	NullToken      string `yaml:"null_token"`
+	FloatPrecision *int   `yaml:"float_precision"`
+	DatetimeLayout string `yaml:"datetime_layout"`
+	DateLayout     string `yaml:"date_layout"`
+	TimeLayout     string `yaml:"time_layout"`
+	Timezone       string `yaml:"timezone"`
+	SourceTimezone string `yaml:"source_timezone"`
+	SchemaSidecar  bool   `yaml:"schema_sidecar"`
 }
 
 type ValidationConfig struct {
-	RequiredColumns []string `yaml:"required_columns"`
-	OptionalColumns []string `yaml:"optional_columns"`
+	RequiredColumns []string              `yaml:"required_columns"`
+	OptionalColumns []string              `yaml:"optional_columns"`
+	Rules           map[string]ColumnRule `yaml:"rules"`
+	ReportFormat    string                `yaml:"report_format"`
+}
+
+// ColumnRule define las restricciones de los valores de una columna. Los
+// valores vacíos solo se verifican con required y required_if
+type ColumnRule struct {
+	Type       string      `yaml:"type"` // string (defecto), int, enum o regex
+	Required   bool        `yaml:"required"`
+	RequiredIf *RequiredIf `yaml:"required_if"`
+	Min        *int64      `yaml:"min"`     // int
+	Max        *int64      `yaml:"max"`     // int
+	Values     []string    `yaml:"values"`  // enum
+	Pattern    string      `yaml:"pattern"` // regex
+}
+
+// RequiredIf exige un valor cuando otra columna tiene alguno de los valores
+// indicados (o cualquier valor si Values está vacío)
+type RequiredIf struct {
+	Column string   `yaml:"column"`
+	Values []string `yaml:"values"`
+}
+
+// ColumnProfile traduce las cabeceras de una planilla externa a las columnas
+// canónicas; se selecciona con --profile
+type ColumnProfile struct {
+	Aliases    map[string][]string `yaml:"aliases"`     // Columna canónica -> nombres alternativos
+	Constants  map[string]string   `yaml:"constants"`   // Columna -> valor fijo para todas las filas
+	IgnoreCase bool                `yaml:"ignore_case"` // Comparar cabeceras sin distinguir mayúsculas
 }
 
 type ProcessingConfig struct {
@@ -67,6 +130,24 @@ type ProcessingConfig struct {
 	BufferSize      int  `yaml:"buffer_size"`
 }
 
+// NamingConfig define las reglas de nombres y paths de los elementos generados.
+// Cada expresión es una plantilla text/template de Go
+type NamingConfig struct {
+	IfsName           string                `yaml:"ifs_name"`
+	PathB             string                `yaml:"path_b"`
+	ImmParentPath     string                `yaml:"imm_parent_path"`
+	BreakerParentPath string                `yaml:"breaker_parent_path"`
+	DefaultSignalType SignalType            `yaml:"default_signal_type"`
+	SignalTypes       map[string]SignalType `yaml:"signal_types"`
+}
+
+// SignalType define el sufijo y los tipos IFS asociados a un valor de TYPE
+type SignalType struct {
+	Suffix  string `yaml:"suffix"`
+	ConType string `yaml:"con_type"`
+	MonType string `yaml:"mon_type"`
+}
+
 // DasipConfig contiene la configuración del mapeo DASIP
 type DasipConfig struct {
 	DefaultPath  string            `yaml:"default_path"`
@@ -141,20 +222,125 @@ func applyDefaults(cfg *AppConfig) {
 		cfg.Processing.BufferSize = 8192
 	}
 
+	// Backend de base de datos
+	if cfg.Database.Backend == "" {
+		cfg.Database.Backend = "csharp"
+	}
+
 	// Directorio de salida
 	if cfg.Files.OutputDir == "" {
 		cfg.Files.OutputDir = "output"
 	}
 
+	// Filas revisadas al buscar la cabecera de los archivos de entrada
+	if cfg.Files.HeaderSearchRows == 0 {
+		cfg.Files.HeaderSearchRows = 20
+	}
+
 	// Formato de timestamp
 	if cfg.Output.TimestampFormat == "" {
 		cfg.Output.TimestampFormat = "20060102_150405"
 	}
 
+	// Sufijos de los formatos de salida
+	if cfg.Output.Suffixes == nil {
+		cfg.Output.Suffixes = map[string]string{}
+	}
+	defaultSuffixes := map[string]string{
+		"imm":        "_IMM.xml",
+		"ifs":        "_IFS.xml",
+		"imm_delete": "_IMM_delete.xml",
+		"ifs_delete": "_IFS_delete.xml",
+		"csv":        ".csv",
+		"xlsx":       ".xlsx",
+		"jsonl":      ".jsonl",
+		"parquet":    ".parquet",
+	}
+	for key, suffix := range defaultSuffixes {
+		if _, ok := cfg.Output.Suffixes[key]; !ok {
+			cfg.Output.Suffixes[key] = suffix
+		}
+	}
+
+	// Formato de valores de direct-query
+	if cfg.Output.Values.DatetimeLayout == "" {
+		cfg.Output.Values.DatetimeLayout = "2006-01-02 15:04:05"
+	}
+
+	if cfg.Output.Values.DateLayout == "" {
+		cfg.Output.Values.DateLayout = "2006-01-02"
+	}
+
+	if cfg.Output.Values.TimeLayout == "" {
+		cfg.Output.Values.TimeLayout = "15:04:05"
+	}
+
+	if cfg.Output.Values.Timezone == "" {
+		cfg.Output.Values.Timezone = "Local"
+	}
+
+	if cfg.Output.Values.SourceTimezone == "" {
+		cfg.Output.Values.SourceTimezone = cfg.Output.Values.Timezone
+	}
+
+	// Reporte de validación de filas
+	if cfg.Validation.ReportFormat == "" {
+		cfg.Validation.ReportFormat = "csv"
+	}
+
 	// Nivel de logging
 	if cfg.Logging.Level == "" {
 		cfg.Logging.Level = "info"
 	}
+
+	// Reglas de nombres (equivalentes al formato histórico)
+	if cfg.Naming.IfsName == "" {
+		cfg.Naming.IfsName = "{{.B1}}_{{.B2}}_{{.B3}}{{if .BAY}}_{{.BAY}}{{end}}_{{.NamePart}}_{{.INFO}}_{{.Suffix}}"
+	}
+
+	if cfg.Naming.PathB == "" {
+		cfg.Naming.PathB = "{{.ImmPath}}/{{.PathPart}}/{{.INFO}}"
+	}
+
+	if cfg.Naming.ImmParentPath == "" {
+		cfg.Naming.ImmParentPath = "ELECTRICITY/NETWORK/{{.EMPRESA}}/{{.REGION}}/{{.B1}}/{{.B2}}/{{.B3}}{{if .BAY}}/{{.BAY}}{{end}}"
+	}
+
+	if cfg.Naming.BreakerParentPath == "" {
+		cfg.Naming.BreakerParentPath = "{{.ImmPath}}/{{.Breaker}}"
+	}
+
+	if cfg.Naming.DefaultSignalType.Suffix == "" {
+		cfg.Naming.DefaultSignalType.Suffix = "M"
+	}
+
+	if cfg.Naming.DefaultSignalType.ConType == "" {
+		cfg.Naming.DefaultSignalType.ConType = "0"
+	}
+
+	if cfg.Naming.DefaultSignalType.MonType == "" {
+		cfg.Naming.DefaultSignalType.MonType = "0"
+	}
+
+	if cfg.Naming.SignalTypes == nil {
+		cfg.Naming.SignalTypes = map[string]SignalType{
+			"SP_SC": {Suffix: "MC", ConType: "45", MonType: "0"},
+		}
+	}
+
+	// Completar los campos omitidos de cada TYPE con los valores por defecto
+	for name, st := range cfg.Naming.SignalTypes {
+		if st.Suffix == "" {
+			st.Suffix = cfg.Naming.DefaultSignalType.Suffix
+		}
+		if st.ConType == "" {
+			st.ConType = cfg.Naming.DefaultSignalType.ConType
+		}
+		if st.MonType == "" {
+			st.MonType = cfg.Naming.DefaultSignalType.MonType
+		}
+		cfg.Naming.SignalTypes[name] = st
+	}
 }
 
 // validate valida la configuración cargada
@@ -177,6 +363,105 @@ func validate(cfg *AppConfig) error {
 		return fmt.Errorf("versión XML no especificada")
 	}
 
+	// Validar backend de base de datos
+	switch cfg.Database.Backend {
+	case "csharp":
+	case "sql", "sqlite":
+		if cfg.Database.DSN == "" {
+			return fmt.Errorf("database.dsn es requerido para el backend '%s'", cfg.Database.Backend)
+		}
+	default:
+		return fmt.Errorf("backend de base de datos desconocido: '%s' (use csharp, sql o sqlite)", cfg.Database.Backend)
+	}
+
+	// Validar reglas de validación de filas
+	switch cfg.Validation.ReportFormat {
+	case "csv", "json":
+	default:
+		return fmt.Errorf("validation.report_format desconocido: '%s' (use csv o json)", cfg.Validation.ReportFormat)
+	}
+	for column, rule := range cfg.Validation.Rules {
+		if err := validateColumnRule(rule); err != nil {
+			return fmt.Errorf("regla validation.rules.%s inválida: %w", column, err)
+		}
+	}
+
+	// Validar perfiles de columnas
+	for name, profile := range cfg.Profiles {
+		if err := validateColumnProfile(profile); err != nil {
+			return fmt.Errorf("perfil profiles.%s inválido: %w", name, err)
+		}
+	}
+
+	// Validar reglas de nombres
+	namingTemplates := map[string]string{
+		"naming.ifs_name":            cfg.Naming.IfsName,
+		"naming.path_b":              cfg.Naming.PathB,
+		"naming.imm_parent_path":     cfg.Naming.ImmParentPath,
+		"naming.breaker_parent_path": cfg.Naming.BreakerParentPath,
+	}
+	for key, text := range namingTemplates {
+		if _, err := template.New(key).Parse(text); err != nil {
+			return fmt.Errorf("plantilla '%s' inválida: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// validateColumnRule verifica que una regla de columna sea coherente
+func validateColumnRule(rule ColumnRule) error {
+	switch rule.Type {
+	case "", "string":
+	case "int":
+		if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+			return fmt.Errorf("min (%d) mayor que max (%d)", *rule.Min, *rule.Max)
+		}
+	case "enum":
+		if len(rule.Values) == 0 {
+			return fmt.Errorf("el tipo enum requiere values")
+		}
+	case "regex":
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("pattern inválido: %w", err)
+		}
+	default:
+		return fmt.Errorf("tipo '%s' desconocido (use string, int, enum o regex)", rule.Type)
+	}
+
+	if rule.RequiredIf != nil && rule.RequiredIf.Column == "" {
+		return fmt.Errorf("required_if requiere column")
+	}
+	return nil
+}
+
+// validateColumnProfile verifica que ningún alias corresponda a dos columnas
+func validateColumnProfile(profile ColumnProfile) error {
+	owners := make(map[string]string)
+	for column, aliases := range profile.Aliases {
+		if column == "" {
+			return fmt.Errorf("alias sin columna canónica")
+		}
+		for _, alias := range append([]string{column}, aliases...) {
+			key := strings.Join(strings.Fields(alias), " ")
+			if profile.IgnoreCase {
+				key = strings.ToLower(key)
+			}
+			if key == "" {
+				return fmt.Errorf("alias vacío en la columna %s", column)
+			}
+			if owner, ok := owners[key]; ok && owner != column {
+				return fmt.Errorf("el alias '%s' corresponde a %s y a %s", alias, owner, column)
+			}
+			owners[key] = column
+		}
+	}
+
+	for column := range profile.Constants {
+		if column == "" {
+			return fmt.Errorf("columna constante sin nombre")
+		}
+	}
 	return nil
 }
 
@@ -193,6 +478,16 @@ func GetIfsParentPath(dasIPVal string) string {
 	return Dasip.DefaultPath
 }
 
+// LookupIfsParentPath retorna el path IFS mapeado para un DASIP e indica si
+// existe un mapeo explícito (GetIfsParentPath usa el path por defecto)
+func LookupIfsParentPath(dasIPVal string) (string, bool) {
+	if Dasip == nil {
+		return "", false
+	}
+	path, exists := Dasip.DasipMapping[dasIPVal]
+	return path, exists
+}
+
 // EnsureOutputDir asegura que el directorio de salida exista
 func EnsureOutputDir() error {
 	if Global == nil {
@@ -236,6 +531,19 @@ func GetTemplatesPath() string {
 	return Global.Files.Templates
 }
 
+// GetSignalType retorna el sufijo y tipos IFS para un valor de TYPE
+func GetSignalType(signalType string) SignalType {
+	if Global == nil {
+		return SignalType{Suffix: "M", ConType: "0", MonType: "0"}
+	}
+
+	if st, exists := Global.Naming.SignalTypes[signalType]; exists {
+		return st
+	}
+
+	return Global.Naming.DefaultSignalType
+}
+
 // GetDasipConfigPath retorna la ruta al archivo de configuración DASIP
 func GetDasipConfigPath() string {
 	if Global == nil {
@@ -0,0 +1,167 @@
+// pkg/database/backend.go
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goScadaSur/pkg/config"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backend define las operaciones disponibles contra la base de datos SURVALENT
+type Backend interface {
+	// StationSearch retorna las señales de una estación
+	StationSearch(ctx context.Context, station Station) (*Result, error)
+
+	// DirectQuery ejecuta una consulta SQL arbitraria
+	DirectQuery(ctx context.Context, query string) (*Result, error)
+
+	// StreamQuery ejecuta una consulta SQL arbitraria entregando las filas al
+	// sink a medida que llegan, sin materializar el resultado. Retorna el
+	// número de filas entregadas
+	StreamQuery(ctx context.Context, query string, sink RowSink) (int64, error)
+
+	// Close libera los recursos del backend
+	Close() error
+}
+
+// Credentials contiene los datos de conexión proporcionados por el usuario
+type Credentials struct {
+	Host     string
+	User     string
+	Password string
+}
+
+// Station identifica la estación a buscar
+type Station struct {
+	B1 string
+	B2 string
+	B3 string
+}
+
+// Column describe una columna del resultado
+type Column struct {
+	Name string
+	Type string
+}
+
+// Result contiene las filas retornadas por una consulta. Los valores NULL se
+// representan con nil
+type Result struct {
+	Columns []Column
+	Rows    [][]any
+}
+
+// RowSink recibe un resultado fila a fila
+type RowSink interface {
+	// Begin se invoca una vez con las columnas antes de la primera fila
+	Begin(columns []Column) error
+
+	// Row se invoca por cada fila; el slice no debe retenerse tras la llamada
+	Row(values []any) error
+}
+
+// Collect retorna un RowSink que materializa las filas en el Result
+func (r *Result) Collect() RowSink {
+	return &resultSink{result: r}
+}
+
+// resultSink acumula las filas recibidas en un Result
+type resultSink struct {
+	result *Result
+}
+
+func (s *resultSink) Begin(columns []Column) error {
+	s.result.Columns = columns
+	return nil
+}
+
+func (s *resultSink) Row(values []any) error {
+	s.result.Rows = append(s.result.Rows, append([]any(nil), values...))
+	return nil
+}
+
+// ColumnNames retorna los nombres de las columnas del resultado
+func (r *Result) ColumnNames() []string {
+	names := make([]string, len(r.Columns))
+	for i, col := range r.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// New crea el backend indicado en la configuración
+func New(cfg config.DatabaseConfig, creds Credentials) (Backend, error) {
+	switch cfg.Backend {
+	case "csharp":
+		return NewCSharpBackend(cfg.CSharpExecutable, creds), nil
+	case "sql":
+		return NewSQLBackend(cfg.Driver, ExpandDSN(cfg.DSN, creds), cfg.StationSearchQuery)
+	case "sqlite":
+		return NewSQLiteFixture(cfg.DSN, cfg.FixtureSeed, cfg.StationSearchQuery)
+	default:
+		return nil, fmt.Errorf("backend de base de datos desconocido: '%s'", cfg.Backend)
+	}
+}
+
+// NeedsCredentials indica si el backend configurado requiere host/usuario/contraseña
+func NeedsCredentials(cfg config.DatabaseConfig) bool {
+	switch cfg.Backend {
+	case "csharp":
+		return true
+	case "sql":
+		return strings.Contains(cfg.DSN, "{host}") ||
+			strings.Contains(cfg.DSN, "{user}") ||
+			strings.Contains(cfg.DSN, "{password}")
+	default:
+		return false
+	}
+}
+
+// ExpandDSN sustituye {host}, {user} y {password} en el DSN configurado
+func ExpandDSN(dsn string, creds Credentials) string {
+	replacer := strings.NewReplacer(
+		"{host}", creds.Host,
+		"{user}", escapeDSN(dsn, creds.User),
+		"{password}", escapeDSN(dsn, creds.Password),
+	)
+	return replacer.Replace(dsn)
+}
+
+// escapeDSN codifica un valor cuando el DSN tiene formato URL
+func escapeDSN(dsn, value string) string {
+	if strings.Contains(dsn, "://") {
+		return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+	}
+	return value
+}
+
+// FormatValue convierte un valor del resultado a texto
+func FormatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case json.Number:
+		return val.String()
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(val)
+	}
+}
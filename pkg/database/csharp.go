@@ -0,0 +1,257 @@
+// pkg/database/csharp.go
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// processWaitDelay es el tiempo máximo de espera tras terminar el proceso C#
+	processWaitDelay = 2 * time.Second
+
+	// streamBufferSize es el tamaño del buffer de lectura en modo streaming
+	streamBufferSize = 64 * 1024
+)
+
+// CSharpInput define la estructura para enviar datos a la aplicación C#
+// (ver protocol.go)
+type CSharpInput struct {
+	ProtocolVersion    int    `json:"protocol_version"`
+	MinProtocolVersion int    `json:"min_protocol_version"`
+	Mode               string `json:"mode"`
+	User               string `json:"user"`
+	Password           string `json:"password"`
+	Host               string `json:"host"`
+	Query              string `json:"query"`
+	B1                 string `json:"b1"`
+	B2                 string `json:"b2"`
+	B3                 string `json:"b3"`
+	Stream             bool   `json:"stream,omitempty"`
+}
+
+// CSharpBackend implementa Backend delegando en el ejecutable survalentDB (C#)
+type CSharpBackend struct {
+	executable string
+	creds      Credentials
+}
+
+// NewCSharpBackend crea un backend que usa el ejecutable C#
+func NewCSharpBackend(executable string, creds Credentials) *CSharpBackend {
+	return &CSharpBackend{
+		executable: executable,
+		creds:      creds,
+	}
+}
+
+// StationSearch busca las señales de una estación
+func (b *CSharpBackend) StationSearch(ctx context.Context, station Station) (*Result, error) {
+	return b.run(ctx, CSharpInput{
+		Mode: "station_search",
+		B1:   station.B1,
+		B2:   station.B2,
+		B3:   station.B3,
+	})
+}
+
+// DirectQuery ejecuta una consulta SQL directa
+func (b *CSharpBackend) DirectQuery(ctx context.Context, query string) (*Result, error) {
+	return b.run(ctx, CSharpInput{
+		Mode:  "direct_query",
+		Query: query,
+	})
+}
+
+// StreamQuery ejecuta una consulta SQL directa en modo streaming (NDJSON)
+func (b *CSharpBackend) StreamQuery(ctx context.Context, query string, sink RowSink) (int64, error) {
+	return b.runStream(ctx, CSharpInput{
+		Mode:   "direct_query",
+		Query:  query,
+		Stream: true,
+	}, sink)
+}
+
+// Close no requiere liberar recursos (cada consulta es un proceso)
+func (b *CSharpBackend) Close() error {
+	return nil
+}
+
+// run ejecuta el proceso C#, verifica la integridad y parsea la respuesta
+func (b *CSharpBackend) run(ctx context.Context, input CSharpInput) (*Result, error) {
+	inputBytes, err := b.encodeInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ejecutar proceso C#. Al cancelar el contexto (timeout o Ctrl-C) el
+	// proceso se termina y se espera como máximo processWaitDelay a que
+	// cierre sus salidas
+	var outBuf, errBuf bytes.Buffer
+	cmd := exec.CommandContext(ctx, b.executable)
+	cmd.Stdin = bytes.NewReader(append(inputBytes, '\n'))
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	cmd.WaitDelay = processWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, contextError(ctxErr, errBuf.String())
+		}
+		if errBuf.Len() > 0 {
+			return nil, fmt.Errorf("%w: %v\n[STDERR] %s", ErrProcess, err, errBuf.String())
+		}
+		return nil, fmt.Errorf("%w: %v", ErrProcess, err)
+	}
+
+	if errBuf.Len() > 0 {
+		fmt.Printf("[WARN] STDERR:\n%s\n", errBuf.String())
+	}
+
+	// Validar versión, errores del helper e integridad
+	payload, version, err := decodeResponse(outBuf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[OK] Verificación de integridad exitosa (protocolo v%d)", version)
+
+	return parsePayload(payload)
+}
+
+// runStream ejecuta el proceso C# leyendo su salida trama a trama
+func (b *CSharpBackend) runStream(ctx context.Context, input CSharpInput, sink RowSink) (int64, error) {
+	inputBytes, err := b.encodeInput(input)
+	if err != nil {
+		return 0, err
+	}
+
+	// Contexto propio para terminar el proceso si se abandona la lectura
+	procCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var errBuf bytes.Buffer
+	cmd := exec.CommandContext(procCtx, b.executable)
+	cmd.Stdin = bytes.NewReader(append(inputBytes, '\n'))
+	cmd.Stderr = &errBuf
+	cmd.WaitDelay = processWaitDelay
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, fmt.Errorf("%w: error creando stdout: %v", ErrProcess, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("%w: error iniciando proceso: %v", ErrProcess, err)
+	}
+
+	count, streamErr := readStream(bufio.NewReaderSize(stdout, streamBufferSize), sink)
+	if streamErr != nil {
+		cancel()
+	}
+	waitErr := cmd.Wait()
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return count, contextError(ctxErr, errBuf.String())
+	}
+
+	// Un stream truncado suele deberse a que el proceso terminó con error
+	if waitErr != nil && (streamErr == nil || isTruncated(streamErr)) {
+		if errBuf.Len() > 0 {
+			return count, fmt.Errorf("%w: %v\n[STDERR] %s", ErrProcess, waitErr, errBuf.String())
+		}
+		return count, fmt.Errorf("%w: %v", ErrProcess, waitErr)
+	}
+
+	if streamErr != nil {
+		return count, streamErr
+	}
+
+	if errBuf.Len() > 0 {
+		fmt.Printf("[WARN] STDERR:\n%s\n", errBuf.String())
+	}
+
+	log.Printf("[OK] Verificación de integridad exitosa (%d filas)", count)
+	return count, nil
+}
+
+// encodeInput completa la versión de protocolo y credenciales de la petición
+func (b *CSharpBackend) encodeInput(input CSharpInput) ([]byte, error) {
+	input.ProtocolVersion = ProtocolVersion
+	input.MinProtocolVersion = MinProtocolVersion
+	input.User = b.creds.User
+	input.Password = b.creds.Password
+	input.Host = b.creds.Host
+
+	inputBytes, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando entrada: %w", err)
+	}
+	return inputBytes, nil
+}
+
+// parsePayload convierte el payload {columns, data, row_count} del proceso C#
+// en un Result
+func parsePayload(payload []byte) (*Result, error) {
+	result := &Result{}
+
+	result.Columns = parseColumns(gjson.GetBytes(payload, "columns"))
+
+	gjson.GetBytes(payload, "data").ForEach(func(_, row gjson.Result) bool {
+		record := make([]any, len(result.Columns))
+		for i, col := range result.Columns {
+			record[i] = jsonValue(row.Get(gjson.Escape(col.Name)))
+		}
+		result.Rows = append(result.Rows, record)
+		return true
+	})
+
+	// Verificar el número de filas declarado (protocolo v2)
+	if rowCount := gjson.GetBytes(payload, "row_count"); rowCount.Exists() {
+		if int(rowCount.Int()) != len(result.Rows) {
+			return nil, fmt.Errorf("%w: se declararon %d filas y se recibieron %d",
+				ErrIntegrity, rowCount.Int(), len(result.Rows))
+		}
+	}
+
+	return result, nil
+}
+
+// parseColumns convierte la lista "columns" del protocolo en columnas
+func parseColumns(columns gjson.Result) []Column {
+	var result []Column
+	columns.ForEach(func(_, col gjson.Result) bool {
+		result = append(result, Column{
+			Name: col.Get("name").String(),
+			Type: col.Get("type").String(),
+		})
+		return true
+	})
+	return result
+}
+
+// jsonValue convierte un valor gjson conservando la representación de los números
+func jsonValue(value gjson.Result) any {
+	switch value.Type {
+	case gjson.Null:
+		return nil
+	case gjson.Number:
+		return json.Number(value.Raw)
+	case gjson.True, gjson.False:
+		return value.Bool()
+	case gjson.String:
+		return value.String()
+	default:
+		if !value.Exists() {
+			return nil
+		}
+		return value.Raw
+	}
+}
@@ -0,0 +1,42 @@
+// pkg/database/errors.go
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrTimeout indica que la operación superó database.connection_timeout
+	ErrTimeout = errors.New("tiempo de espera agotado")
+
+	// ErrCanceled indica que el usuario canceló la operación (Ctrl-C)
+	ErrCanceled = errors.New("operación cancelada")
+
+	// ErrIntegrity indica que la respuesta no pudo verificarse o está corrupta
+	ErrIntegrity = errors.New("error de integridad")
+
+	// ErrProtocol indica que la respuesta del helper no sigue el protocolo
+	// esperado o usa una versión no soportada
+	ErrProtocol = errors.New("error de protocolo")
+
+	// ErrProcess indica que el proceso o la consulta terminaron con error
+	ErrProcess = errors.New("error en proceso")
+)
+
+// contextError traduce el error del contexto a ErrTimeout o ErrCanceled,
+// adjuntando la salida de error parcial si la hay
+func contextError(ctxErr error, stderr string) error {
+	base := ErrCanceled
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		base = ErrTimeout
+	}
+
+	stderr = strings.TrimSpace(stderr)
+	if stderr != "" {
+		return fmt.Errorf("%w\n[STDERR parcial] %s", base, stderr)
+	}
+	return base
+}
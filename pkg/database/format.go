@@ -0,0 +1,233 @@
+// pkg/database/format.go
+package database
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"goScadaSur/pkg/config"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnKind clasifica el tipo declarado de una columna
+type ColumnKind string
+
+const (
+	KindString   ColumnKind = "string"
+	KindInteger  ColumnKind = "integer"
+	KindFloat    ColumnKind = "float"
+	KindDecimal  ColumnKind = "decimal"
+	KindBool     ColumnKind = "bool"
+	KindDateTime ColumnKind = "datetime"
+	KindDate     ColumnKind = "date"
+	KindTime     ColumnKind = "time"
+	KindBinary   ColumnKind = "binary"
+)
+
+// columnKinds asocia los nombres de tipo de SQL Server y SQLite a su categoría
+var columnKinds = map[string]ColumnKind{
+	"int": KindInteger, "integer": KindInteger, "bigint": KindInteger,
+	"smallint": KindInteger, "tinyint": KindInteger,
+	"float": KindFloat, "real": KindFloat, "double": KindFloat,
+	"decimal": KindDecimal, "numeric": KindDecimal, "money": KindDecimal, "smallmoney": KindDecimal,
+	"bit": KindBool, "bool": KindBool, "boolean": KindBool,
+	"datetime": KindDateTime, "datetime2": KindDateTime, "smalldatetime": KindDateTime,
+	"datetimeoffset": KindDateTime, "timestamp": KindDateTime,
+	"date":   KindDate,
+	"time":   KindTime,
+	"binary": KindBinary, "varbinary": KindBinary, "image": KindBinary, "blob": KindBinary,
+}
+
+// datetimeLayouts son los formatos aceptados al interpretar fechas recibidas como texto
+var datetimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+// KindOf retorna la categoría de un tipo de columna ("nvarchar(50)" → string)
+func KindOf(columnType string) ColumnKind {
+	name := strings.ToLower(strings.TrimSpace(columnType))
+	if idx := strings.IndexByte(name, '('); idx >= 0 {
+		name = strings.TrimSpace(name[:idx])
+	}
+	if kind, ok := columnKinds[name]; ok {
+		return kind
+	}
+	return KindString
+}
+
+// ValueFormatter convierte los valores de un resultado a texto según el tipo
+// de su columna (ver output.values)
+type ValueFormatter struct {
+	nullToken      string
+	floatPrecision int
+	datetimeLayout string
+	dateLayout     string
+	timeLayout     string
+	location       *time.Location
+	sourceLocation *time.Location
+}
+
+// NewValueFormatter crea un formateador a partir de la configuración
+func NewValueFormatter(cfg config.ValueFormatConfig) (*ValueFormatter, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("zona horaria inválida '%s': %w", cfg.Timezone, err)
+	}
+
+	sourceLocation, err := time.LoadLocation(cfg.SourceTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("zona horaria de origen inválida '%s': %w", cfg.SourceTimezone, err)
+	}
+
+	precision := -1
+	if cfg.FloatPrecision != nil {
+		precision = *cfg.FloatPrecision
+	}
+
+	return &ValueFormatter{
+		nullToken:      cfg.NullToken,
+		floatPrecision: precision,
+		datetimeLayout: cfg.DatetimeLayout,
+		dateLayout:     cfg.DateLayout,
+		timeLayout:     cfg.TimeLayout,
+		location:       location,
+		sourceLocation: sourceLocation,
+	}, nil
+}
+
+// Format convierte un valor de la columna indicada a texto
+func (f *ValueFormatter) Format(column Column, v any) string {
+	if v == nil {
+		return f.nullToken
+	}
+
+	switch KindOf(column.Type) {
+	case KindFloat:
+		if number, ok := toFloat(v); ok {
+			return strconv.FormatFloat(number, 'f', f.floatPrecision, 64)
+		}
+	case KindDecimal:
+		// Se conserva el texto exacto para no perder dígitos significativos
+		if text, ok := v.(json.Number); ok {
+			return text.String()
+		}
+	case KindDateTime:
+		if t, ok := f.toTime(v); ok {
+			return t.In(f.location).Format(f.datetimeLayout)
+		}
+	case KindDate:
+		if t, ok := f.toTime(v); ok {
+			return t.Format(f.dateLayout)
+		}
+	case KindTime:
+		if t, ok := f.toTime(v); ok {
+			return t.Format(f.timeLayout)
+		}
+	case KindBinary:
+		switch raw := v.(type) {
+		case []byte:
+			return BinaryText(raw)
+		case string:
+			if !strings.HasPrefix(raw, "0x") {
+				return BinaryText([]byte(raw))
+			}
+		}
+	}
+
+	return FormatValue(v)
+}
+
+// BinaryText representa un valor binario como texto hexadecimal (0x0A1B...)
+func BinaryText(raw []byte) string {
+	return "0x" + strings.ToUpper(hex.EncodeToString(raw))
+}
+
+// toTime interpreta un valor como fecha. Los valores sin zona horaria se
+// asumen en la zona de origen configurada
+func (f *ValueFormatter) toTime(v any) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case string:
+		for _, layout := range datetimeLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(val), f.sourceLocation); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// toFloat convierte un valor numérico a float64
+func toFloat(v any) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case json.Number:
+		number, err := val.Float64()
+		return number, err == nil
+	case string:
+		number, err := strconv.ParseFloat(val, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// SchemaColumn describe una columna en el archivo .schema.json
+type SchemaColumn struct {
+	Name string     `json:"name"`
+	Type string     `json:"type"`
+	Kind ColumnKind `json:"kind"`
+}
+
+// Schema describe el contenido de un archivo de resultados
+type Schema struct {
+	Columns        []SchemaColumn `json:"columns"`
+	RowCount       int64          `json:"row_count"`
+	NullToken      string         `json:"null_token"`
+	FloatPrecision int            `json:"float_precision"`
+	DatetimeLayout string         `json:"datetime_layout"`
+	DateLayout     string         `json:"date_layout"`
+	TimeLayout     string         `json:"time_layout"`
+	Timezone       string         `json:"timezone"`
+}
+
+// WriteSchema escribe el archivo .schema.json que describe las columnas y el
+// formato con el que se escribieron los valores
+func (f *ValueFormatter) WriteSchema(path string, columns []Column, rowCount int64) error {
+	schema := Schema{
+		Columns:        make([]SchemaColumn, len(columns)),
+		RowCount:       rowCount,
+		NullToken:      f.nullToken,
+		FloatPrecision: f.floatPrecision,
+		DatetimeLayout: f.datetimeLayout,
+		DateLayout:     f.dateLayout,
+		TimeLayout:     f.timeLayout,
+		Timezone:       f.location.String(),
+	}
+	for i, col := range columns {
+		schema.Columns[i] = SchemaColumn{Name: col.Name, Type: col.Type, Kind: KindOf(col.Type)}
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando esquema: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error escribiendo esquema '%s': %w", path, err)
+	}
+	return nil
+}
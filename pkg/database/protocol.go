@@ -0,0 +1,130 @@
+// pkg/database/protocol.go
+package database
+
+// Protocolo entre goScadaSur y survalentDB.exe
+//
+// Petición (una línea JSON por stdin):
+//
+//	{
+//	  "protocol_version": 3,          // versión máxima que entiende goScadaSur
+//	  "min_protocol_version": 1,      // versión mínima aceptada
+//	  "mode": "station_search" | "direct_query",
+//	  "user": "...", "password": "...", "host": "...",
+//	  "query": "...",                 // direct_query
+//	  "b1": "...", "b2": "...", "b3": "...", // station_search
+//	  "stream": true                  // opcional, ver stream.go (v3)
+//	}
+//
+// Respuesta (JSON por stdout):
+//
+//	{
+//	  "protocol_version": 2,          // versión usada por el helper
+//	  "status": "ok" | "error",
+//	  "payload": {
+//	    "columns": [{"name": "PKEY", "type": "int"}, ...],
+//	    "data": [{"PKEY": 1, ...}, ...],
+//	    "row_count": 1
+//	  },
+//	  "checksum": "<sha256 hex del payload tal como se emitió>",
+//	  "error": {"code": "...", "message": "...", "sql_state": "..."}
+//	}
+//
+// Una respuesta sin protocol_version se interpreta como versión 1 (solo
+// payload y checksum, sin error tipado ni row_count).
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// ProtocolVersion es la versión máxima del protocolo soportada
+	ProtocolVersion = 3
+
+	// streamProtocolVersion es la versión mínima que soporta streaming
+	streamProtocolVersion = 3
+
+	// MinProtocolVersion es la versión mínima del protocolo aceptada
+	MinProtocolVersion = 1
+
+	// maxOutputSnippet limita la salida no reconocida que se muestra en errores
+	maxOutputSnippet = 512
+)
+
+// HelperError es el error tipado que reporta survalentDB.exe
+type HelperError struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	SQLState string `json:"sql_state,omitempty"`
+}
+
+func (e *HelperError) Error() string {
+	msg := fmt.Sprintf("[%s] %s", e.Code, e.Message)
+	if e.SQLState != "" {
+		msg += fmt.Sprintf(" (SQLSTATE %s)", e.SQLState)
+	}
+	return msg
+}
+
+// helperResponse es la respuesta de survalentDB.exe
+type helperResponse struct {
+	ProtocolVersion int             `json:"protocol_version"`
+	Status          string          `json:"status"`
+	Payload         json.RawMessage `json:"payload"`
+	Checksum        string          `json:"checksum"`
+	Error           *HelperError    `json:"error"`
+}
+
+// decodeResponse valida la respuesta del helper (versión, errores e
+// integridad) y retorna el payload verificado
+func decodeResponse(output []byte) (json.RawMessage, int, error) {
+	var resp helperResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, 0, fmt.Errorf("%w: respuesta no reconocida de survalentDB (%v):\n%s",
+			ErrProtocol, err, snippet(output))
+	}
+
+	// Las respuestas sin versión corresponden al protocolo 1
+	version := resp.ProtocolVersion
+	if version == 0 {
+		version = 1
+	}
+
+	if version < MinProtocolVersion || version > ProtocolVersion {
+		return nil, version, fmt.Errorf("%w: survalentDB usa el protocolo v%d y goScadaSur soporta v%d a v%d",
+			ErrProtocol, version, MinProtocolVersion, ProtocolVersion)
+	}
+
+	if resp.Error != nil || resp.Status == "error" {
+		if resp.Error == nil {
+			resp.Error = &HelperError{Code: "UNKNOWN", Message: "el helper reportó un error sin detalle"}
+		}
+		return nil, version, fmt.Errorf("%w: %w", ErrProcess, resp.Error)
+	}
+
+	if len(resp.Payload) == 0 || resp.Checksum == "" {
+		return nil, version, fmt.Errorf("%w: respuesta JSON inválida (falta payload o checksum)", ErrIntegrity)
+	}
+
+	hasher := sha256.New()
+	hasher.Write(resp.Payload)
+	calculatedChecksum := hex.EncodeToString(hasher.Sum(nil))
+
+	if !strings.EqualFold(calculatedChecksum, resp.Checksum) {
+		return nil, version, fmt.Errorf("%w: los datos pueden estar corruptos", ErrIntegrity)
+	}
+
+	return resp.Payload, version, nil
+}
+
+// snippet retorna el inicio de una salida no reconocida
+func snippet(output []byte) string {
+	text := strings.TrimSpace(string(output))
+	if len(text) > maxOutputSnippet {
+		return text[:maxOutputSnippet] + "..."
+	}
+	return text
+}
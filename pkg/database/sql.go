@@ -0,0 +1,139 @@
+// pkg/database/sql.go
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/microsoft/go-mssqldb"
+)
+
+// SQLBackend implementa Backend sobre database/sql
+type SQLBackend struct {
+	db           *sql.DB
+	stationQuery string
+}
+
+// NewSQLBackend abre una conexión con el driver y DSN indicados. La consulta
+// de estación recibe B1, B2 y B3 como parámetros posicionales
+func NewSQLBackend(driver, dsn, stationQuery string) (*SQLBackend, error) {
+	if driver == "" {
+		driver = "sqlserver"
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo conexión '%s': %w", driver, err)
+	}
+
+	return &SQLBackend{
+		db:           db,
+		stationQuery: stationQuery,
+	}, nil
+}
+
+// StationSearch busca las señales de una estación con la consulta configurada
+func (b *SQLBackend) StationSearch(ctx context.Context, station Station) (*Result, error) {
+	if b.stationQuery == "" {
+		return nil, fmt.Errorf("database.station_search_query no está configurada")
+	}
+	return b.query(ctx, b.stationQuery, station.B1, station.B2, station.B3)
+}
+
+// DirectQuery ejecuta una consulta SQL directa
+func (b *SQLBackend) DirectQuery(ctx context.Context, query string) (*Result, error) {
+	return b.query(ctx, query)
+}
+
+// Close cierra la conexión
+func (b *SQLBackend) Close() error {
+	return b.db.Close()
+}
+
+// StreamQuery ejecuta una consulta SQL directa entregando las filas al sink
+func (b *SQLBackend) StreamQuery(ctx context.Context, query string, sink RowSink) (int64, error) {
+	return b.stream(ctx, sink, query)
+}
+
+// query ejecuta una consulta y materializa el resultado
+func (b *SQLBackend) query(ctx context.Context, query string, args ...any) (*Result, error) {
+	result := &Result{}
+	if _, err := b.stream(ctx, result.Collect(), query, args...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// stream ejecuta una consulta y entrega cada fila al sink
+func (b *SQLBackend) stream(ctx context.Context, sink RowSink, query string, args ...any) (int64, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, queryError(ctx, "error ejecutando consulta", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, fmt.Errorf("error obteniendo columnas: %w", err)
+	}
+
+	columns := make([]Column, len(columnTypes))
+	binary := make([]bool, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = Column{
+			Name: ct.Name(),
+			Type: ct.DatabaseTypeName(),
+		}
+		binary[i] = KindOf(columns[i].Type) == KindBinary
+	}
+
+	if err := sink.Begin(columns); err != nil {
+		return 0, err
+	}
+
+	values := make([]any, len(columnTypes))
+	pointers := make([]any, len(columnTypes))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	var count int64
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return count, queryError(ctx, "error leyendo fila", err)
+		}
+
+		// Los drivers retornan texto como []byte; se copia para no retener el
+		// buffer. Las columnas binarias se escriben en hexadecimal para no
+		// producir UTF-8 inválido en la salida
+		for i, v := range values {
+			if raw, ok := v.([]byte); ok {
+				if binary[i] {
+					values[i] = BinaryText(raw)
+				} else {
+					values[i] = string(raw)
+				}
+			}
+		}
+
+		if err := sink.Row(values); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, queryError(ctx, "error recorriendo resultados", err)
+	}
+
+	return count, nil
+}
+
+// queryError clasifica un error de consulta como timeout, cancelación o error de proceso
+func queryError(ctx context.Context, msg string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return contextError(ctxErr, "")
+	}
+	return fmt.Errorf("%w: %s: %v", ErrProcess, msg, err)
+}
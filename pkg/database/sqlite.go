@@ -0,0 +1,37 @@
+// pkg/database/sqlite.go
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+// NewSQLiteFixture abre una base SQLite local que imita las tablas de
+// SURVALENT. Si se indica un script de carga, se ejecuta al abrir, lo que
+// permite probar los comandos en Linux sin acceso al servidor real
+func NewSQLiteFixture(path, seedPath, stationQuery string) (*SQLBackend, error) {
+	backend, err := NewSQLBackend("sqlite", path, stationQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if seedPath != "" {
+		seed, err := os.ReadFile(seedPath)
+		if err != nil {
+			backend.Close()
+			return nil, fmt.Errorf("error leyendo script de fixture '%s': %w", seedPath, err)
+		}
+
+		if _, err := backend.db.Exec(string(seed)); err != nil {
+			backend.Close()
+			return nil, fmt.Errorf("error cargando fixture '%s': %w", seedPath, err)
+		}
+
+		log.Printf("[OK] Fixture SQLite cargado: %s", seedPath)
+	}
+
+	return backend, nil
+}
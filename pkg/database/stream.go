@@ -0,0 +1,175 @@
+// pkg/database/stream.go
+package database
+
+// Modo streaming del protocolo (v3)
+//
+// Cuando la petición incluye "stream": true, survalentDB.exe responde con una
+// trama JSON por línea (NDJSON):
+//
+//	{"type":"header","protocol_version":3,"columns":[{"name":"PKEY","type":"int"}]}
+//	{"type":"row","data":{"PKEY":10111027}}
+//	...
+//	{"type":"trailer","row_count":1,"checksum":"<sha256 hex>"}
+//
+// El checksum es el SHA-256 de las líneas header y row, cada una sin su fin
+// de línea original y seguida de "\n". En cualquier punto el helper puede
+// emitir {"type":"error","error":{...}} para abortar. Si la primera línea no
+// es una trama (JSON con "type"), se asume un helper sin streaming y se
+// procesa la respuesta completa como en el modo normal, aunque esté indentada
+// en varias líneas.
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// errStreamTruncated indica que el stream terminó antes del trailer
+var errStreamTruncated = fmt.Errorf("%w: el stream terminó sin trailer", ErrIntegrity)
+
+// readStream procesa las tramas NDJSON del helper, entrega cada fila al sink y
+// verifica el checksum de forma incremental
+func readStream(r *bufio.Reader, sink RowSink) (int64, error) {
+	hasher := sha256.New()
+	var columns []Column
+	var values []any
+	var count int64
+	first := true
+
+	for {
+		line, err := readLine(r)
+		if err != nil && err != io.EOF {
+			return count, fmt.Errorf("%w: error leyendo stream: %v", ErrProcess, err)
+		}
+		if len(line) == 0 {
+			if err == io.EOF {
+				return count, errStreamTruncated
+			}
+			continue
+		}
+
+		// Helper sin soporte de streaming: respuesta completa en un solo
+		// documento, que puede ocupar varias líneas si está indentado
+		if first && (!gjson.ValidBytes(line) || !gjson.GetBytes(line, "type").Exists()) {
+			return readBufferedResponse(line, r, sink)
+		}
+		first = false
+
+		if !gjson.ValidBytes(line) {
+			return count, fmt.Errorf("%w: trama no reconocida:\n%s", ErrProtocol, snippet(line))
+		}
+		frameType := gjson.GetBytes(line, "type").String()
+
+		switch frameType {
+		case "header":
+			version := int(gjson.GetBytes(line, "protocol_version").Int())
+			if version < streamProtocolVersion || version > ProtocolVersion {
+				return count, fmt.Errorf("%w: survalentDB usa el protocolo v%d para streaming y goScadaSur soporta v%d a v%d",
+					ErrProtocol, version, streamProtocolVersion, ProtocolVersion)
+			}
+
+			columns = parseColumns(gjson.GetBytes(line, "columns"))
+			values = make([]any, len(columns))
+			if err := sink.Begin(columns); err != nil {
+				return count, err
+			}
+			hashLine(hasher, line)
+
+		case "row":
+			if columns == nil {
+				return count, fmt.Errorf("%w: fila recibida antes del header", ErrProtocol)
+			}
+
+			data := gjson.GetBytes(line, "data")
+			for i, col := range columns {
+				values[i] = jsonValue(data.Get(gjson.Escape(col.Name)))
+			}
+			if err := sink.Row(values); err != nil {
+				return count, err
+			}
+			hashLine(hasher, line)
+			count++
+
+		case "trailer":
+			if rowCount := gjson.GetBytes(line, "row_count"); rowCount.Exists() && rowCount.Int() != count {
+				return count, fmt.Errorf("%w: se declararon %d filas y se recibieron %d",
+					ErrIntegrity, rowCount.Int(), count)
+			}
+
+			calculated := hex.EncodeToString(hasher.Sum(nil))
+			if !strings.EqualFold(calculated, gjson.GetBytes(line, "checksum").String()) {
+				return count, fmt.Errorf("%w: los datos pueden estar corruptos", ErrIntegrity)
+			}
+			return count, nil
+
+		case "error":
+			helperErr := &HelperError{
+				Code:     gjson.GetBytes(line, "error.code").String(),
+				Message:  gjson.GetBytes(line, "error.message").String(),
+				SQLState: gjson.GetBytes(line, "error.sql_state").String(),
+			}
+			return count, fmt.Errorf("%w: %w", ErrProcess, helperErr)
+
+		default:
+			return count, fmt.Errorf("%w: tipo de trama desconocido '%s'", ErrProtocol, frameType)
+		}
+
+		if err == io.EOF {
+			return count, errStreamTruncated
+		}
+	}
+}
+
+// readBufferedResponse procesa la respuesta de un helper sin streaming y
+// entrega sus filas al sink
+func readBufferedResponse(firstLine []byte, r io.Reader, sink RowSink) (int64, error) {
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return 0, fmt.Errorf("%w: error leyendo respuesta: %v", ErrProcess, err)
+	}
+
+	payload, _, err := decodeResponse(append(append(firstLine, '\n'), rest...))
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := parsePayload(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := sink.Begin(result.Columns); err != nil {
+		return 0, err
+	}
+	for _, row := range result.Rows {
+		if err := sink.Row(row); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(result.Rows)), nil
+}
+
+// readLine lee una línea completa sin el fin de línea (\n o \r\n)
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	return bytes.TrimRight(line, "\r\n"), err
+}
+
+// hashLine agrega una trama al checksum incremental
+func hashLine(hasher io.Writer, line []byte) {
+	hasher.Write(line)
+	hasher.Write([]byte{'\n'})
+}
+
+// isTruncated indica si el error corresponde a un stream incompleto
+func isTruncated(err error) bool {
+	return errors.Is(err, errStreamTruncated)
+}
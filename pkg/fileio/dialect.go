@@ -0,0 +1,301 @@
+// pkg/fileio/dialect.go
+package fileio
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Codificaciones de entrada soportadas
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingLatin1      = "latin1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+const (
+	// sniffSize es la cantidad de bytes inspeccionados para detectar el dialecto
+	sniffSize = 64 * 1024
+
+	// sniffRecords es la cantidad de registros inspeccionados para detectar el
+	// delimitador
+	sniffRecords = 20
+
+	// defaultDelimiter se usa cuando ningún candidato aparece en la muestra
+	defaultDelimiter = ','
+)
+
+// delimiterCandidates son los delimitadores probados, en orden de preferencia
+var delimiterCandidates = []rune{',', ';', '\t', '|'}
+
+// Marcas de orden de bytes reconocidas
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Dialect describe el delimitador y la codificación de un archivo CSV
+type Dialect struct {
+	Delimiter rune
+	Encoding  string
+	BOM       bool // El archivo comenzaba con una marca de orden de bytes
+}
+
+// String retorna una descripción legible del dialecto
+func (d Dialect) String() string {
+	s := fmt.Sprintf("delimitador %s, codificación %s", delimiterName(d.Delimiter), d.Encoding)
+	if d.BOM {
+		s += " con BOM"
+	}
+	return s
+}
+
+// ParseDelimiter interpreta el delimitador indicado por el usuario. Vacío o
+// "auto" retorna 0 (detección automática)
+func ParseDelimiter(value string) (rune, error) {
+	switch strings.ToLower(value) {
+	case "", "auto":
+		return 0, nil
+	case ",", "comma":
+		return ',', nil
+	case ";", "semicolon":
+		return ';', nil
+	case "\t", `\t`, "tab":
+		return '\t', nil
+	case "|", "pipe":
+		return '|', nil
+	default:
+		return 0, fmt.Errorf("delimitador '%s' no soportado (use auto, ',', ';', tab o '|')", value)
+	}
+}
+
+// ParseEncoding normaliza el nombre de codificación indicado por el usuario.
+// Vacío o "auto" retorna "" (detección automática)
+func ParseEncoding(value string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(value, "_", "-")) {
+	case "", "auto":
+		return "", nil
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "utf-16", "utf16", "utf-16le", "utf16le":
+		return EncodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, nil
+	case "latin1", "latin-1", "iso-8859-1", "iso8859-1":
+		return EncodingLatin1, nil
+	case "windows-1252", "cp1252", "win1252":
+		return EncodingWindows1252, nil
+	default:
+		return "", fmt.Errorf("codificación '%s' no soportada (use auto, utf-8, utf-16le, utf-16be, latin1 o windows-1252)", value)
+	}
+}
+
+// openDialect inspecciona el inicio de input y retorna un reader que entrega
+// el contenido en UTF-8 sin BOM, junto con el dialecto elegido. delimiter y
+// enc fuerzan el valor correspondiente; cero/vacío activan la detección
+func openDialect(input *bufio.Reader, delimiter rune, enc string) (io.Reader, Dialect, error) {
+	sample, err := input.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, Dialect{}, err
+	}
+
+	dialect := Dialect{Encoding: enc}
+	bomLen := 0
+
+	// La BOM define la codificación salvo que el usuario la fuerce
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		if enc == "" || enc == EncodingUTF8 {
+			dialect.Encoding, bomLen = EncodingUTF8, len(bomUTF8)
+		}
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		if enc == "" || enc == EncodingUTF16LE {
+			dialect.Encoding, bomLen = EncodingUTF16LE, len(bomUTF16LE)
+		}
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		if enc == "" || enc == EncodingUTF16BE {
+			dialect.Encoding, bomLen = EncodingUTF16BE, len(bomUTF16BE)
+		}
+	}
+	dialect.BOM = bomLen > 0
+
+	if dialect.Encoding == "" {
+		dialect.Encoding = detectEncoding(sample)
+	}
+
+	if _, err := input.Discard(bomLen); err != nil {
+		return nil, Dialect{}, err
+	}
+	sample = sample[bomLen:]
+
+	decoder := decoderFor(dialect.Encoding)
+
+	dialect.Delimiter = delimiter
+	if dialect.Delimiter == 0 {
+		dialect.Delimiter = sniffDelimiter(decodeSample(sample, dialect.Encoding, decoder))
+	}
+
+	if decoder == nil {
+		return input, dialect, nil
+	}
+	return transform.NewReader(input, decoder.NewDecoder()), dialect, nil
+}
+
+// detectEncoding deduce la codificación de una muestra sin BOM. Los bytes
+// nulos alternados indican UTF-16; lo que no es UTF-8 válido se asume
+// Windows-1252 (superconjunto imprimible de Latin-1)
+func detectEncoding(sample []byte) string {
+	if len(sample) >= 2 {
+		evenZeros, oddZeros := 0, 0
+		for i := 0; i+1 < len(sample); i += 2 {
+			if sample[i] == 0 {
+				evenZeros++
+			}
+			if sample[i+1] == 0 {
+				oddZeros++
+			}
+		}
+
+		pairs := len(sample) / 2
+		switch {
+		case oddZeros > pairs/2 && evenZeros == 0:
+			return EncodingUTF16LE
+		case evenZeros > pairs/2 && oddZeros == 0:
+			return EncodingUTF16BE
+		}
+	}
+
+	if utf8.Valid(trimPartialRune(sample)) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// trimPartialRune quita una secuencia UTF-8 incompleta al final de la muestra
+func trimPartialRune(sample []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(sample); i++ {
+		start := len(sample) - i
+		if utf8.RuneStart(sample[start]) {
+			if !utf8.FullRune(sample[start:]) {
+				return sample[:start]
+			}
+			break
+		}
+	}
+	return sample
+}
+
+// decoderFor retorna el decodificador a UTF-8 de una codificación, o nil si
+// el contenido ya está en UTF-8
+func decoderFor(enc string) encoding.Encoding {
+	switch enc {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	case EncodingLatin1:
+		return charmap.ISO8859_1
+	case EncodingWindows1252:
+		return charmap.Windows1252
+	default:
+		return nil
+	}
+}
+
+// decodeSample convierte la muestra a UTF-8 para buscar el delimitador
+func decodeSample(sample []byte, enc string, decoder encoding.Encoding) string {
+	if decoder == nil {
+		return string(sample)
+	}
+	if enc == EncodingUTF16LE || enc == EncodingUTF16BE {
+		sample = sample[:len(sample)&^1]
+	}
+	decoded, _, err := transform.Bytes(decoder.NewDecoder(), sample)
+	if err != nil {
+		return string(sample)
+	}
+	return string(decoded)
+}
+
+// sniffDelimiter elige el candidato cuya cantidad de apariciones fuera de
+// comillas se repite en más registros de la muestra. Los registros donde el
+// candidato no aparece (títulos antes de la cabecera, líneas vacías) no
+// cuentan. Los empates se resuelven por la cantidad de apariciones y luego
+// por el orden de delimiterCandidates
+func sniffDelimiter(sample string) rune {
+	records := countDelimiters(sample, sniffRecords)
+
+	best, bestRecords, bestCount := rune(defaultDelimiter), 0, 0
+	for _, candidate := range delimiterCandidates {
+		count, matches := consistentCount(records, candidate)
+		if matches > bestRecords || (matches == bestRecords && count > bestCount) {
+			best, bestRecords, bestCount = candidate, matches, count
+		}
+	}
+	return best
+}
+
+// countDelimiters cuenta los candidatos fuera de comillas en los primeros
+// registros de la muestra. Un salto de línea entre comillas no termina el
+// registro
+func countDelimiters(sample string, maxRecords int) []map[rune]int {
+	var records []map[rune]int
+	counts := make(map[rune]int, len(delimiterCandidates))
+	inQuotes := false
+	for _, r := range sample {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == '\n' && !inQuotes:
+			records = append(records, counts)
+			if len(records) == maxRecords {
+				return records
+			}
+			counts = make(map[rune]int, len(delimiterCandidates))
+		case !inQuotes:
+			counts[r]++
+		}
+	}
+	if len(counts) > 0 {
+		records = append(records, counts)
+	}
+	return records
+}
+
+// consistentCount retorna la cantidad de apariciones del candidato que más se
+// repite entre los registros donde aparece, y en cuántos registros se repite
+func consistentCount(records []map[rune]int, candidate rune) (count, matches int) {
+	frequency := make(map[int]int)
+	for _, counts := range records {
+		if n := counts[candidate]; n > 0 {
+			frequency[n]++
+		}
+	}
+
+	for n, records := range frequency {
+		if records > matches || (records == matches && n > count) {
+			count, matches = n, records
+		}
+	}
+	return count, matches
+}
+
+// delimiterName retorna el delimitador en forma legible
+func delimiterName(delimiter rune) string {
+	if delimiter == '\t' {
+		return "tab"
+	}
+	return fmt.Sprintf("'%c'", delimiter)
+}
@@ -0,0 +1,120 @@
+// pkg/fileio/excelcells.go
+package fileio
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// mergedValue es un rango combinado con el valor de su celda superior izquierda
+type mergedValue struct {
+	area  *cellArea
+	value string
+}
+
+// loadMerged lee los rangos combinados de la hoja para completarlos en cada
+// fila. excelize carga la hoja completa en memoria para obtenerlos
+func (r *ExcelReader) loadMerged() error {
+	merges, err := r.file.GetMergeCells(r.sheetName, true)
+	if err != nil {
+		return fmt.Errorf("error leyendo celdas combinadas de la hoja '%s': %w", r.sheetName, err)
+	}
+
+	for _, m := range merges {
+		area, err := parseArea(m.GetStartAxis() + ":" + m.GetEndAxis())
+		if err != nil {
+			return fmt.Errorf("celdas combinadas de la hoja '%s': %w", r.sheetName, err)
+		}
+		value, err := r.cellValue(m.GetStartAxis())
+		if err != nil {
+			return err
+		}
+		if value != "" {
+			r.merged = append(r.merged, mergedValue{area: area, value: value})
+		}
+	}
+	return nil
+}
+
+// fillMerged copia el valor de los rangos combinados que cubren la fila actual
+func (r *ExcelReader) fillMerged(values []string) []string {
+	for _, m := range r.merged {
+		if !m.area.containsRow(r.rowNum) {
+			continue
+		}
+		if len(values) < m.area.lastCol {
+			padded := make([]string, m.area.lastCol)
+			copy(padded, values)
+			values = padded
+		}
+		for col := m.area.firstCol; col <= m.area.lastCol; col++ {
+			values[col-1] = m.value
+		}
+	}
+	return values
+}
+
+// evaluateRow reemplaza el resultado guardado de cada fórmula de la fila
+// actual por el valor calculado, para que la lectura no dependa de si el
+// libro se recalculó antes de guardarlo
+func (r *ExcelReader) evaluateRow(values []string) ([]string, error) {
+	for col := 1; col <= max(len(values), r.lastCol); col++ {
+		cell, err := excelize.CoordinatesToCellName(col, r.rowNum)
+		if err != nil {
+			return nil, err
+		}
+		value, ok, err := r.formulaValue(cell)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if len(values) < col {
+			padded := make([]string, col)
+			copy(padded, values)
+			values = padded
+		}
+		values[col-1] = value
+	}
+	return values, nil
+}
+
+// cellValue retorna el valor de una celda, calculando su fórmula si se
+// indicó EvalFormulas
+func (r *ExcelReader) cellValue(cell string) (string, error) {
+	if r.formulas {
+		value, ok, err := r.formulaValue(cell)
+		if err != nil || ok {
+			return value, err
+		}
+	}
+
+	value, err := r.file.GetCellValue(r.sheetName, cell)
+	if err != nil {
+		return "", fmt.Errorf("error leyendo celda %s de la hoja '%s': %w", cell, r.sheetName, err)
+	}
+	return value, nil
+}
+
+// formulaValue calcula la fórmula de una celda con CalcCellValue. ok es false
+// si la celda no tiene fórmula o no se pudo calcular; en ese caso se conserva
+// el resultado guardado en el libro
+func (r *ExcelReader) formulaValue(cell string) (string, bool, error) {
+	formula, err := r.file.GetCellFormula(r.sheetName, cell)
+	if err != nil {
+		return "", false, fmt.Errorf("error leyendo fórmula %s de la hoja '%s': %w", cell, r.sheetName, err)
+	}
+	if formula == "" {
+		return "", false, nil
+	}
+
+	value, err := r.file.CalcCellValue(r.sheetName, cell)
+	if err != nil {
+		log.Printf("[WARN] Celda %s: no se pudo calcular '=%s' (%v); se usa el valor guardado", cell, formula, err)
+		return "", false, nil
+	}
+	return value, true, nil
+}
@@ -0,0 +1,183 @@
+// pkg/fileio/header.go
+package fileio
+
+import (
+	"io"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// totalsPattern reconoce las filas de totales que las planillas agregan al final
+var totalsPattern = regexp.MustCompile(`(?i)^(sub)?total(es)?\b`)
+
+// locateHeader busca la fila de cabecera: la primera de las HeaderSearchRows
+// filas iniciales que contiene todas las HeaderColumns (con el perfil de
+// columnas aplicado). Las filas anteriores (título, logo...) se descartan. Si
+// ninguna coincide se usa la primera fila no vacía y las leídas después se
+// vuelven a entregar, para que la validación de columnas reporte las faltantes
+func locateHeader(reader DataReader, opts ReadOptions) (Row, DataReader, error) {
+	if len(opts.HeaderColumns) == 0 || opts.HeaderSearchRows <= 1 {
+		row, err := reader.Next()
+		return row, reader, err
+	}
+
+	var scanned []Row
+	for len(scanned) < opts.HeaderSearchRows {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Row{}, nil, err
+		}
+
+		if isHeaderRow(row.Values, opts) {
+			if len(scanned) > 0 {
+				log.Printf("[INFO] Cabecera detectada en la fila %d", row.Number)
+			}
+			return row, reader, nil
+		}
+		scanned = append(scanned, row)
+	}
+
+	for i, row := range scanned {
+		if len(row.Values) > 0 {
+			return row, &replayReader{DataReader: reader, pending: scanned[i+1:]}, nil
+		}
+	}
+	if len(scanned) > 0 {
+		return scanned[0], reader, nil
+	}
+	return Row{}, nil, io.EOF
+}
+
+// isHeaderRow indica si la fila contiene todas las columnas que identifican
+// la cabecera
+func isHeaderRow(values []string, opts ReadOptions) bool {
+	if len(values) == 0 {
+		return false
+	}
+
+	names := make(map[string]bool, len(values))
+	if opts.Mapping != nil {
+		for _, name := range opts.Mapping.canonicalNames(values) {
+			names[name] = true
+		}
+		for column := range opts.Mapping.Constants {
+			names[column] = true
+		}
+	} else {
+		for _, value := range values {
+			names[strings.TrimSpace(value)] = true
+		}
+	}
+
+	for _, column := range opts.HeaderColumns {
+		if !names[column] {
+			return false
+		}
+	}
+	return true
+}
+
+// replayReader entrega primero las filas ya leídas al buscar la cabecera
+type replayReader struct {
+	DataReader
+	pending []Row
+}
+
+// Next retorna las filas pendientes y luego continúa con el reader
+func (r *replayReader) Next() (Row, error) {
+	if len(r.pending) > 0 {
+		row := r.pending[0]
+		r.pending = r.pending[1:]
+		return row, nil
+	}
+	return r.DataReader.Next()
+}
+
+// ReadAll lee todos los registros, incluidas las filas pendientes
+func (r *replayReader) ReadAll() ([][]string, error) {
+	return readAll(r)
+}
+
+// trailerReader omite las filas vacías y de totales al final de los datos.
+// Esas filas se retienen hasta saber si les sigue otra fila con datos
+type trailerReader struct {
+	DataReader
+	held   []Row // Filas vacías o de totales retenidas
+	resume *Row  // Fila con datos leída después de las retenidas
+}
+
+// Next retorna la siguiente fila; al terminar descarta las filas retenidas
+func (r *trailerReader) Next() (Row, error) {
+	if len(r.held) > 0 && r.resume != nil {
+		row := r.held[0]
+		r.held = r.held[1:]
+		return row, nil
+	}
+	if r.resume != nil {
+		row := *r.resume
+		r.resume = nil
+		return row, nil
+	}
+
+	for {
+		row, err := r.DataReader.Next()
+		if err == io.EOF {
+			totals := 0
+			for _, held := range r.held {
+				if !isBlankRow(held.Values) {
+					totals++
+				}
+			}
+			if totals > 0 {
+				log.Printf("[INFO] Se omiten %d filas de totales al final de los datos", totals)
+			}
+			r.held = nil
+			return Row{}, io.EOF
+		}
+		if err != nil {
+			return Row{}, err
+		}
+
+		if isBlankRow(row.Values) || isTotalsRow(row.Values) {
+			r.held = append(r.held, row)
+			continue
+		}
+		if len(r.held) == 0 {
+			return row, nil
+		}
+
+		// Las filas retenidas no estaban al final: se entregan en orden
+		r.resume = &row
+		return r.Next()
+	}
+}
+
+// ReadAll lee todos los registros sin las filas finales omitidas
+func (r *trailerReader) ReadAll() ([][]string, error) {
+	return readAll(r)
+}
+
+// isBlankRow indica si todas las celdas de la fila están vacías
+func isBlankRow(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isTotalsRow indica si la primera celda con valor empieza con "Total" o
+// "Subtotal"
+func isTotalsRow(values []string) bool {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return totalsPattern.MatchString(value)
+		}
+	}
+	return false
+}
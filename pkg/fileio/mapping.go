@@ -0,0 +1,140 @@
+// pkg/fileio/mapping.go
+package fileio
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ColumnMapping normaliza las cabeceras de una planilla externa a los nombres
+// de columna canónicos (ELEMENT, B3, MHB...) antes de validar las filas
+type ColumnMapping struct {
+	// Name es el nombre del perfil, solo para los mensajes
+	Name string
+
+	// Aliases asocia cada columna canónica con sus nombres alternativos. La
+	// columna canónica también se reconoce por su propio nombre
+	Aliases map[string][]string
+
+	// Constants agrega (o reemplaza) columnas con un valor fijo en todas las filas
+	Constants map[string]string
+
+	// IgnoreCase compara las cabeceras sin distinguir mayúsculas
+	IgnoreCase bool
+}
+
+// headerKey normaliza una cabecera para compararla: sin espacios sobrantes y,
+// con IgnoreCase, en minúsculas
+func (m *ColumnMapping) headerKey(header string) string {
+	key := strings.Join(strings.Fields(header), " ")
+	if m.IgnoreCase {
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+// canonicalNames retorna el nombre canónico de cada cabecera; las cabeceras
+// sin alias conservan su texto sin espacios sobrantes
+func (m *ColumnMapping) canonicalNames(headers []string) []string {
+	canonical := make(map[string]string)
+	for column, aliases := range m.Aliases {
+		for _, name := range append([]string{column}, aliases...) {
+			canonical[m.headerKey(name)] = column
+		}
+	}
+
+	names := make([]string, len(headers))
+	for i, header := range headers {
+		names[i] = strings.TrimSpace(header)
+		if column, ok := canonical[m.headerKey(header)]; ok {
+			names[i] = column
+		}
+	}
+	return names
+}
+
+// apply traduce las cabeceras leídas a sus nombres canónicos y envuelve el
+// reader para completar las columnas constantes de cada fila
+func (m *ColumnMapping) apply(reader DataReader, headers []string) (DataReader, []string, error) {
+	mapped := m.canonicalNames(headers)
+	source := make(map[string]string) // Columna canónica -> cabecera original
+	var renamed []string
+	for i, name := range mapped {
+		header := strings.TrimSpace(headers[i])
+		if name != "" {
+			if previous, dup := source[name]; dup {
+				return nil, nil, fmt.Errorf("perfil '%s': las cabeceras '%s' y '%s' corresponden a la columna %s", m.Name, previous, header, name)
+			}
+			source[name] = header
+		}
+		if name != header {
+			renamed = append(renamed, fmt.Sprintf("%s -> %s", header, name))
+		}
+	}
+
+	// Orden estable para que las columnas agregadas sean reproducibles
+	columns := make([]string, 0, len(m.Constants))
+	for column := range m.Constants {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	constants := make(map[int]string, len(columns))
+	for _, column := range columns {
+		idx := -1
+		for i, name := range mapped {
+			if name == column {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = len(mapped)
+			mapped = append(mapped, column)
+		}
+		constants[idx] = m.Constants[column]
+	}
+
+	log.Printf("[INFO] Perfil de columnas '%s': %d cabeceras renombradas, %d columnas constantes", m.Name, len(renamed), len(constants))
+	if len(renamed) > 0 {
+		log.Printf("[INFO] Cabeceras: %s", strings.Join(renamed, ", "))
+	}
+
+	if len(constants) == 0 {
+		return reader, mapped, nil
+	}
+	return &constantReader{DataReader: reader, width: len(mapped), constants: constants}, mapped, nil
+}
+
+// constantReader completa las columnas constantes de un perfil en cada fila
+type constantReader struct {
+	DataReader
+	width     int
+	constants map[int]string // Índice de columna -> valor
+}
+
+// Next lee la siguiente fila y asigna los valores constantes. Las filas vacías
+// se entregan sin cambios para que se sigan omitiendo
+func (r *constantReader) Next() (Row, error) {
+	row, err := r.DataReader.Next()
+	if err != nil || len(row.Values) == 0 {
+		return row, err
+	}
+
+	if len(row.Values) < r.width {
+		values := make([]string, r.width)
+		copy(values, row.Values)
+		row.Values = values
+	}
+	for idx, value := range r.constants {
+		row.Values[idx] = value
+	}
+	return row, nil
+}
+
+// ReadAll lee todos los registros aplicando las columnas constantes
+func (r *constantReader) ReadAll() ([][]string, error) {
+	return readAll(r)
+}
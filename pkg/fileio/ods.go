@@ -0,0 +1,360 @@
+// pkg/fileio/ods.go
+package fileio
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Espacios de nombres de OpenDocument utilizados
+const (
+	odsTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odsOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+)
+
+// ODSReader implementa DataReader para hojas de cálculo OpenDocument (.ods).
+// content.xml se recorre con un decodificador XML incremental
+type ODSReader struct {
+	archive   *zip.ReadCloser
+	content   io.ReadCloser
+	decoder   *xml.Decoder
+	sheetName string
+	rowNum    int
+	blankRows int      // Filas vacías pendientes de entregar
+	pending   []string // Fila leída que espera a que se entreguen las vacías
+	repeated  int      // Copias pendientes de pending (number-rows-repeated)
+	lastRow   []string // Última fila con datos entregada
+	done      bool
+}
+
+// NewODSReader crea un lector para la hoja indicada (nombre o índice desde 1)
+// de un archivo .ods. Sin hoja se lee la primera
+func NewODSReader(filePath, sheet string) (*ODSReader, error) {
+	sheets, err := ListODSSheets(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetName, err := resolveSheet(sheets, sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, content, err := openODSContent(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ODSReader{
+		archive:   archive,
+		content:   content,
+		decoder:   xml.NewDecoder(content),
+		sheetName: sheetName,
+	}
+
+	// Avanzar hasta la tabla seleccionada
+	for {
+		start, err := r.nextStart("table")
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("error leyendo hoja ODS '%s': %w", sheetName, err)
+		}
+		if odsAttr(start, odsTableNS, "name") == sheetName {
+			return r, nil
+		}
+		if err := r.decoder.Skip(); err != nil {
+			r.Close()
+			return nil, fmt.Errorf("error leyendo hoja ODS '%s': %w", sheetName, err)
+		}
+	}
+}
+
+// ListODSSheets retorna los nombres de las hojas de un archivo .ods en orden
+func ListODSSheets(filePath string) ([]string, error) {
+	archive, content, err := openODSContent(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	defer content.Close()
+
+	var sheets []string
+	decoder := xml.NewDecoder(content)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo archivo ODS: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Space == odsTableNS && start.Name.Local == "table" {
+			sheets = append(sheets, odsAttr(start, odsTableNS, "name"))
+			if err := decoder.Skip(); err != nil {
+				return nil, fmt.Errorf("error leyendo archivo ODS: %w", err)
+			}
+		}
+	}
+
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("no se encontraron hojas en el archivo ODS")
+	}
+	return sheets, nil
+}
+
+// openODSContent abre el archivo y su content.xml
+func openODSContent(filePath string) (*zip.ReadCloser, io.ReadCloser, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error abriendo archivo ODS: %w", err)
+	}
+
+	for _, file := range archive.File {
+		if file.Name == "content.xml" {
+			content, err := file.Open()
+			if err != nil {
+				archive.Close()
+				return nil, nil, fmt.Errorf("error abriendo archivo ODS: %w", err)
+			}
+			return archive, content, nil
+		}
+	}
+
+	archive.Close()
+	return nil, nil, fmt.Errorf("error abriendo archivo ODS: content.xml no encontrado")
+}
+
+// Next retorna la siguiente fila de la hoja. Las filas vacías repetidas al
+// final de la hoja no se entregan
+func (r *ODSReader) Next() (Row, error) {
+	for r.blankRows == 0 && r.pending == nil && r.repeated == 0 {
+		if r.done {
+			return Row{}, io.EOF
+		}
+		if err := r.readRow(); err != nil {
+			return Row{}, fmt.Errorf("error leyendo hoja ODS '%s': %w", r.sheetName, err)
+		}
+	}
+
+	r.rowNum++
+	switch {
+	case r.blankRows > 0:
+		r.blankRows--
+		return Row{Number: r.rowNum, Values: nil}, nil
+	case r.pending != nil:
+		r.lastRow, r.pending = r.pending, nil
+		return Row{Number: r.rowNum, Values: r.lastRow}, nil
+	default:
+		r.repeated--
+		return Row{Number: r.rowNum, Values: slices.Clone(r.lastRow)}, nil
+	}
+}
+
+// readRow lee el siguiente table:table-row de la hoja. Una fila repetida con
+// valores deja las copias restantes como filas pendientes
+func (r *ODSReader) readRow() error {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != odsTableNS {
+				continue
+			}
+			if t.Name.Local != "table-row" {
+				continue
+			}
+
+			repeat := odsRepeat(t, "number-rows-repeated")
+			values, err := r.readCells()
+			if err != nil {
+				return err
+			}
+
+			if len(values) == 0 {
+				// Se acumulan y solo se entregan si aparece una fila con datos
+				r.blankRows += repeat
+				continue
+			}
+
+			// Las filas repetidas con datos se entregan una a una
+			r.pending = values
+			r.repeated = repeat - 1
+			return nil
+		case xml.EndElement:
+			if t.Name.Space == odsTableNS && t.Name.Local == "table" {
+				r.done = true
+				r.blankRows = 0
+				return nil
+			}
+		}
+	}
+}
+
+// readCells lee las celdas de la fila actual hasta su cierre
+func (r *ODSReader) readCells() ([]string, error) {
+	var values []string
+	blank := 0 // Celdas vacías pendientes: solo se agregan si sigue un valor
+
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Space != odsTableNS {
+				continue
+			}
+			if t.Name.Local != "table-cell" && t.Name.Local != "covered-table-cell" {
+				continue
+			}
+
+			repeat := odsRepeat(t, "number-columns-repeated")
+			value, err := r.readCellText()
+			if err != nil {
+				return nil, err
+			}
+
+			// Las celdas cubiertas por una combinación quedan vacías, como en .xlsx
+			if t.Name.Local == "covered-table-cell" {
+				value = ""
+			}
+
+			if value == "" {
+				blank += repeat
+				continue
+			}
+			for ; blank > 0; blank-- {
+				values = append(values, "")
+			}
+			for i := 0; i < repeat; i++ {
+				values = append(values, value)
+			}
+		case xml.EndElement:
+			if t.Name.Space == odsTableNS && t.Name.Local == "table-row" {
+				return values, nil
+			}
+		}
+	}
+}
+
+// readCellText retorna el texto visible de la celda actual: sus párrafos
+// text:p unidos por saltos de línea
+func (r *ODSReader) readCellText() (string, error) {
+	var paragraphs []string
+	var current strings.Builder
+	depth := 1
+	inParagraph := false
+
+	for depth > 0 {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			// Los comentarios de celda no forman parte del valor
+			if t.Name.Space == odsOfficeNS && t.Name.Local == "annotation" {
+				if err := r.decoder.Skip(); err != nil {
+					return "", err
+				}
+				continue
+			}
+			depth++
+			if t.Name.Space != odsTextNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "p", "h":
+				inParagraph = true
+				current.Reset()
+			case "s":
+				current.WriteString(strings.Repeat(" ", odsRepeat(t, "c")))
+			case "tab":
+				current.WriteByte('\t')
+			case "line-break":
+				current.WriteByte('\n')
+			}
+		case xml.EndElement:
+			depth--
+			if t.Name.Space == odsTextNS && (t.Name.Local == "p" || t.Name.Local == "h") && inParagraph {
+				paragraphs = append(paragraphs, current.String())
+				inParagraph = false
+			}
+		case xml.CharData:
+			if inParagraph {
+				current.Write(t)
+			}
+		}
+	}
+
+	return strings.Join(paragraphs, "\n"), nil
+}
+
+// nextStart avanza hasta el siguiente elemento table:<local>
+func (r *ODSReader) nextStart(local string) (xml.StartElement, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			return xml.StartElement{}, fmt.Errorf("hoja no encontrada en content.xml")
+		}
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Space == odsTableNS && start.Name.Local == local {
+			return start, nil
+		}
+	}
+}
+
+// ReadAll lee todos los registros de la hoja
+func (r *ODSReader) ReadAll() ([][]string, error) {
+	return readAll(r)
+}
+
+// Close cierra el archivo ODS
+func (r *ODSReader) Close() error {
+	if r.content != nil {
+		r.content.Close()
+	}
+	if r.archive != nil {
+		return r.archive.Close()
+	}
+	return nil
+}
+
+// odsAttr retorna el valor de un atributo con espacio de nombres
+func odsAttr(start xml.StartElement, space, local string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == space && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// odsRepeat retorna el contador de repetición de un elemento (mínimo 1)
+func odsRepeat(start xml.StartElement, local string) int {
+	space := odsTableNS
+	if local == "c" {
+		space = odsTextNS
+	}
+	n, err := strconv.Atoi(odsAttr(start, space, local))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
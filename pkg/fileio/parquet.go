@@ -0,0 +1,147 @@
+// pkg/fileio/parquet.go
+package fileio
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetBatchSize es el número de filas que se acumulan antes de escribir
+const parquetBatchSize = 1024
+
+// ParquetTableWriter escribe un archivo Parquet con una columna opcional por
+// columna del resultado (INT64, DOUBLE o STRING según su tipo)
+type ParquetTableWriter struct {
+	file    *os.File
+	writer  *parquet.Writer
+	columns []TableColumn
+	leaves  []int
+	batch   []parquet.Row
+	rows    int
+}
+
+// NewParquetTableWriter crea un nuevo escritor de Parquet
+func NewParquetTableWriter(filePath string) (*ParquetTableWriter, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error creando archivo Parquet: %w", err)
+	}
+
+	return &ParquetTableWriter{file: file}, nil
+}
+
+// WriteHeader construye el esquema a partir de las columnas
+func (w *ParquetTableWriter) WriteHeader(columns []TableColumn) error {
+	group := parquet.Group{}
+	for _, col := range columns {
+		if _, exists := group[col.Name]; exists {
+			return fmt.Errorf("columna duplicada '%s': Parquet requiere nombres únicos", col.Name)
+		}
+
+		var node parquet.Node
+		switch col.Type {
+		case IntegerColumn:
+			node = parquet.Int(64)
+		case NumberColumn:
+			node = parquet.Leaf(parquet.DoubleType)
+		default:
+			node = parquet.String()
+		}
+		group[col.Name] = parquet.Optional(node)
+	}
+
+	schema := parquet.NewSchema("result", group)
+
+	// El esquema ordena las columnas por nombre; se guarda el índice de cada una
+	w.leaves = make([]int, len(columns))
+	for i, col := range columns {
+		leaf, _ := schema.Lookup(col.Name)
+		w.leaves[i] = leaf.ColumnIndex
+	}
+
+	w.columns = columns
+	w.writer = parquet.NewWriter(w.file, schema, parquet.Compression(&parquet.Snappy))
+	return nil
+}
+
+// WriteRow agrega una fila al lote actual
+func (w *ParquetTableWriter) WriteRow(cells []Cell) error {
+	w.rows++
+	row := make(parquet.Row, len(cells))
+	for i, cell := range cells {
+		value, err := parquetValue(w.columns[i].Type, cell)
+		if err != nil {
+			return fmt.Errorf("fila %d, columna '%s': %w", w.rows, w.columns[i].Name, err)
+		}
+		row[w.leaves[i]] = value.Level(0, definitionLevel(cell), w.leaves[i])
+	}
+
+	w.batch = append(w.batch, row)
+	if len(w.batch) >= parquetBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close escribe el lote pendiente y el pie del archivo
+func (w *ParquetTableWriter) Close() error {
+	defer w.file.Close()
+
+	if w.writer == nil {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("error finalizando archivo Parquet: %w", err)
+	}
+	return w.file.Close()
+}
+
+// flush escribe el lote acumulado
+func (w *ParquetTableWriter) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+	if _, err := w.writer.WriteRows(w.batch); err != nil {
+		return fmt.Errorf("error escribiendo filas Parquet: %w", err)
+	}
+	w.batch = w.batch[:0]
+	return nil
+}
+
+// parquetValue convierte una celda al valor de su columna
+func parquetValue(columnType ColumnType, cell Cell) (parquet.Value, error) {
+	if cell.Null {
+		return parquet.NullValue(), nil
+	}
+
+	switch columnType {
+	case IntegerColumn:
+		number, err := strconv.ParseInt(cell.Value, 10, 64)
+		if err != nil {
+			return parquet.Value{}, fmt.Errorf("valor entero inválido '%s'", cell.Value)
+		}
+		return parquet.Int64Value(number), nil
+	case NumberColumn:
+		number, err := strconv.ParseFloat(cell.Value, 64)
+		if err != nil {
+			return parquet.Value{}, fmt.Errorf("valor numérico inválido '%s'", cell.Value)
+		}
+		return parquet.DoubleValue(number), nil
+	default:
+		return parquet.ByteArrayValue([]byte(cell.Value)), nil
+	}
+}
+
+// definitionLevel retorna 0 para NULL y 1 para valores presentes (columnas opcionales)
+func definitionLevel(cell Cell) int {
+	if cell.Null {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,156 @@
+// pkg/fileio/ranges.go
+package fileio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// cellArea es un rango rectangular de celdas (coordenadas desde 1, inclusivas)
+type cellArea struct {
+	firstCol, firstRow int
+	lastCol, lastRow   int
+}
+
+// containsRow indica si la fila está dentro del rango
+func (a *cellArea) containsRow(row int) bool {
+	return row >= a.firstRow && row <= a.lastRow
+}
+
+// slice recorta los valores de una fila a las columnas del rango. Una fila
+// sin valores dentro del rango se retorna vacía
+func (a *cellArea) slice(values []string) []string {
+	if a.firstCol-1 >= len(values) {
+		return nil
+	}
+	values = values[a.firstCol-1 : min(a.lastCol, len(values))]
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return values
+		}
+	}
+	return nil
+}
+
+// resolveRange busca name como tabla de Excel (ListObject), luego como
+// nombre definido y por último como referencia literal (A6:K40) en la hoja
+// indicada. Sin distinguir mayúsculas. Retorna la hoja y el rango encontrados
+func resolveRange(file *excelize.File, sheet, name string) (string, *cellArea, error) {
+	sheets := file.GetSheetList()
+
+	// Tablas de Excel
+	for _, sheetName := range sheets {
+		tables, err := file.GetTables(sheetName)
+		if err != nil {
+			return "", nil, fmt.Errorf("error leyendo tablas de la hoja '%s': %w", sheetName, err)
+		}
+		for _, table := range tables {
+			if strings.EqualFold(table.Name, name) {
+				area, err := parseArea(table.Range)
+				if err != nil {
+					return "", nil, fmt.Errorf("tabla '%s': %w", table.Name, err)
+				}
+				return sheetName, area, nil
+			}
+		}
+	}
+
+	// Nombres definidos: primero los de ámbito de la hoja indicada
+	var match *excelize.DefinedName
+	for _, dn := range file.GetDefinedName() {
+		if !strings.EqualFold(dn.Name, name) {
+			continue
+		}
+		if match == nil || (sheet != "" && strings.EqualFold(dn.Scope, sheet)) {
+			match = &dn
+		}
+	}
+	if match != nil {
+		sheetName, ref, ok := splitSheetRef(match.RefersTo)
+		if !ok {
+			return "", nil, fmt.Errorf("el nombre '%s' no se refiere a un rango de celdas (%s)", match.Name, match.RefersTo)
+		}
+		area, err := parseArea(ref)
+		if err != nil {
+			return "", nil, fmt.Errorf("nombre '%s': %w", match.Name, err)
+		}
+		return sheetName, area, nil
+	}
+
+	// Referencia literal (A6:K40) sobre la hoja seleccionada
+	if strings.Contains(name, ":") {
+		if area, err := parseArea(name); err == nil {
+			sheetName, err := resolveSheet(sheets, sheet)
+			if err != nil {
+				return "", nil, err
+			}
+			return sheetName, area, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("rango '%s' no encontrado: no es una tabla, un nombre definido ni una referencia A1:B2", name)
+}
+
+// splitSheetRef separa una referencia como 'Mi hoja'!$A$6:$K$40 en hoja y
+// rango. Las referencias a varias áreas o fórmulas no se admiten
+func splitSheetRef(refersTo string) (string, string, bool) {
+	refersTo = strings.TrimPrefix(strings.TrimSpace(refersTo), "=")
+	idx := strings.LastIndex(refersTo, "!")
+	if idx <= 0 || strings.ContainsAny(refersTo[idx+1:], ",()") {
+		return "", "", false
+	}
+
+	sheet := refersTo[:idx]
+	if strings.HasPrefix(sheet, "'") && strings.HasSuffix(sheet, "'") && len(sheet) >= 2 {
+		sheet = strings.ReplaceAll(sheet[1:len(sheet)-1], "''", "'")
+	}
+	return sheet, refersTo[idx+1:], true
+}
+
+// parseArea interpreta una referencia A6:K40, A6 o A:K (columnas completas)
+func parseArea(ref string) (*cellArea, error) {
+	ref = strings.ReplaceAll(strings.TrimSpace(ref), "$", "")
+	first, last, found := strings.Cut(ref, ":")
+	if !found {
+		last = first
+	}
+
+	firstCol, firstRow, err := parseCellRef(first)
+	if err != nil {
+		return nil, err
+	}
+	lastCol, lastRow, err := parseCellRef(last)
+	if err != nil {
+		return nil, err
+	}
+
+	// Columnas completas (A:K)
+	if firstRow == 0 && lastRow == 0 {
+		firstRow, lastRow = 1, excelize.TotalRows
+	}
+	if firstRow == 0 || lastRow == 0 {
+		return nil, fmt.Errorf("referencia '%s' inválida", ref)
+	}
+
+	return &cellArea{
+		firstCol: min(firstCol, lastCol),
+		firstRow: min(firstRow, lastRow),
+		lastCol:  max(firstCol, lastCol),
+		lastRow:  max(firstRow, lastRow),
+	}, nil
+}
+
+// parseCellRef convierte A6 en (1, 6) y A en (1, 0)
+func parseCellRef(cell string) (int, int, error) {
+	if cell != "" && strings.Trim(cell, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") == "" {
+		col, err := excelize.ColumnNameToNumber(cell)
+		return col, 0, err
+	}
+	col, row, err := excelize.CellNameToCoordinates(cell)
+	if err != nil {
+		return 0, 0, fmt.Errorf("referencia '%s' inválida", cell)
+	}
+	return col, row, nil
+}
@@ -2,77 +2,191 @@
 package fileio
 
 import (
+	"bufio"
 	"encoding/csv"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/xuri/excelize/v2"
 )
 
+var (
+	// ErrNoData indica que el archivo u hoja no tiene cabecera y datos
+	ErrNoData = errors.New("sin datos")
+
+	// ErrMissingColumns indica que faltan columnas requeridas
+	ErrMissingColumns = errors.New("columnas requeridas faltantes")
+)
+
+// ReadOptions contiene las opciones de lectura de un archivo de entrada
+type ReadOptions struct {
+	// Sheet es la hoja Excel a leer (nombre o índice desde 1). Vacío usa la
+	// primera hoja; se ignora en formatos sin hojas
+	Sheet string
+
+	// BufferSize es el tamaño del buffer de lectura (processing.buffer_size)
+	BufferSize int
+
+	// Delimiter fuerza el delimitador CSV; 0 lo detecta desde la cabecera
+	Delimiter rune
+
+	// Encoding fuerza la codificación CSV (ver ParseEncoding); vacío la
+	// detecta desde la BOM o el contenido
+	Encoding string
+
+	// Range limita la lectura a una tabla de Excel, un nombre definido o una
+	// referencia A1:B2 (solo .xlsx)
+	Range string
+
+	// HeaderColumns son las columnas que identifican la fila de cabecera; se
+	// buscan en las primeras HeaderSearchRows filas. Vacío usa la primera fila
+	HeaderColumns    []string
+	HeaderSearchRows int
+
+	// FillMerged copia el valor de cada rango combinado en todas sus celdas
+	// (por defecto solo la celda superior izquierda tiene valor). Solo Excel
+	FillMerged bool
+
+	// EvalFormulas recalcula las fórmulas con excelize en lugar de usar el
+	// resultado guardado en el libro. Solo .xlsx
+	EvalFormulas bool
+
+	// Mapping traduce las cabeceras a los nombres canónicos (--profile). nil
+	// usa las cabeceras tal como están en el archivo
+	Mapping *ColumnMapping
+}
+
+// Row es una fila leída de forma incremental
+type Row struct {
+	Number int      // Número de fila en el archivo (la primera es la fila 1)
+	Values []string // Valores de las celdas
+}
+
 // DataReader define la interfaz común para leer datos tabulares
 type DataReader interface {
+	// Next retorna la siguiente fila o io.EOF al terminar
+	Next() (Row, error)
 	ReadAll() ([][]string, error)
 	Close() error
 }
 
 // CSVReader implementa DataReader para archivos CSV
 type CSVReader struct {
-	file   *os.File
-	reader *csv.Reader
+	file    *os.File
+	reader  *csv.Reader
+	dialect Dialect
 }
 
-// ExcelReader implementa DataReader para archivos Excel
+// ExcelReader implementa DataReader para archivos Excel, recorriendo la hoja
+// con el iterador de filas de excelize
 type ExcelReader struct {
 	file      *excelize.File
 	sheetName string
-	rows      [][]string
+	area      *cellArea // Rango seleccionado con --range; nil lee la hoja completa
+	rows      *excelize.Rows
+	rowNum    int
+	formulas  bool          // Recalcular las fórmulas (EvalFormulas)
+	lastCol   int           // Última columna de la hoja con datos (EvalFormulas)
+	merged    []mergedValue // Rangos combinados a completar (FillMerged)
 }
 
 // NewDataReader crea un reader apropiado basado en la extensión del archivo
-func NewDataReader(filePath string) (DataReader, error) {
+func NewDataReader(filePath string, opts ReadOptions) (DataReader, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
 	case ".csv":
-		return NewCSVReader(filePath)
-	case ".xlsx", ".xls":
-		return NewExcelReader(filePath)
+		return NewCSVReader(filePath, opts)
+	case ".xlsx":
+		return NewExcelReader(filePath, opts)
+	case ".xls":
+		// Algunos .xls son libros OOXML renombrados
+		legacy, err := isCFB(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if legacy {
+			if opts.Range != "" {
+				return nil, fmt.Errorf("--range no está disponible para libros .xls 97-2003 (guarde el archivo como .xlsx)")
+			}
+			if opts.EvalFormulas {
+				log.Printf("[WARN] Las fórmulas de libros .xls 97-2003 usan el resultado guardado en el archivo")
+			}
+			return NewXLSReader(filePath, opts.Sheet, opts.FillMerged)
+		}
+		return NewExcelReader(filePath, opts)
+	case ".ods":
+		return NewODSReader(filePath, opts.Sheet)
+	case ".json":
+		return NewJSONReader(filePath)
+	case ".yaml", ".yml":
+		return NewYAMLReader(filePath)
 	default:
-		return nil, fmt.Errorf("formato de archivo no soportado: %s (use .csv, .xlsx o .xls)", ext)
+		return nil, fmt.Errorf("formato de archivo no soportado: %s (use .csv, .xlsx, .xls, .ods, .json o .yaml)", ext)
 	}
 }
 
-// NewCSVReader crea un nuevo lector de CSV
-func NewCSVReader(filePath string) (*CSVReader, error) {
+// NewCSVReader crea un nuevo lector de CSV. El delimitador y la codificación
+// se detectan salvo que opts los fuerce; el contenido se convierte a UTF-8.
+// opts.BufferSize <= 0 usa el tamaño por defecto de bufio
+func NewCSVReader(filePath string, opts ReadOptions) (*CSVReader, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("error abriendo archivo CSV: %w", err)
 	}
 
-	reader := csv.NewReader(file)
+	buffered := bufio.NewReader(file)
+	if opts.BufferSize > 0 {
+		buffered = bufio.NewReaderSize(file, opts.BufferSize)
+	}
+
+	input, dialect, err := openDialect(buffered, opts.Delimiter, opts.Encoding)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error detectando dialecto CSV: %w", err)
+	}
+
+	reader := csv.NewReader(input)
+	reader.Comma = dialect.Delimiter
 	reader.FieldsPerRecord = -1 // Permitir número variable de campos
 	reader.TrimLeadingSpace = true
 
 	return &CSVReader{
-		file:   file,
-		reader: reader,
+		file:    file,
+		reader:  reader,
+		dialect: dialect,
 	}, nil
 }
 
-// ReadAll lee todos los registros del CSV
-func (r *CSVReader) ReadAll() ([][]string, error) {
-	records, err := r.reader.ReadAll()
+// Dialect retorna el delimitador y la codificación usados para leer el archivo
+func (r *CSVReader) Dialect() Dialect {
+	return r.dialect
+}
+
+// Next lee el siguiente registro del CSV
+func (r *CSVReader) Next() (Row, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error leyendo CSV: %w", err)
+		return Row{}, fmt.Errorf("error leyendo CSV: %w", err)
 	}
 
-	if len(records) < 2 {
-		return nil, fmt.Errorf("el archivo CSV debe tener al menos una cabecera y una fila de datos")
-	}
+	line, _ := r.reader.FieldPos(0)
+	return Row{Number: line, Values: record}, nil
+}
 
-	return records, nil
+// ReadAll lee todos los registros del CSV
+func (r *CSVReader) ReadAll() ([][]string, error) {
+	return readAll(r)
 }
 
 // Close cierra el archivo CSV
@@ -83,85 +197,306 @@ func (r *CSVReader) Close() error {
 	return nil
 }
 
-// NewExcelReader crea un nuevo lector de Excel
-func NewExcelReader(filePath string) (*ExcelReader, error) {
+// NewExcelReader crea un nuevo lector de Excel para la hoja indicada (nombre
+// o índice desde 1). Sin hoja se lee la primera. opts.Range limita la lectura
+// a una tabla, un nombre definido o una referencia A1:B2 (ver resolveRange)
+func NewExcelReader(filePath string, opts ReadOptions) (*ExcelReader, error) {
 	file, err := excelize.OpenFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("error abriendo archivo Excel: %w", err)
 	}
 
-	// Obtener la primera hoja (o la hoja activa)
-	sheetName := file.GetSheetName(0)
-	if sheetName == "" {
-		return nil, fmt.Errorf("no se encontraron hojas en el archivo Excel")
+	r := &ExcelReader{file: file, formulas: opts.EvalFormulas}
+	if err := r.selectSheet(opts.Sheet, opts.Range); err != nil {
+		file.Close()
+		return nil, err
 	}
 
-	return &ExcelReader{
-		file:      file,
-		sheetName: sheetName,
-	}, nil
+	// Las fórmulas sin resultado guardado pueden estar después de la última
+	// celda con valor de la fila
+	if opts.EvalFormulas {
+		if dimension, err := file.GetSheetDimension(r.sheetName); err == nil {
+			if area, err := parseArea(dimension); err == nil {
+				r.lastCol = area.lastCol
+			}
+		}
+	}
+
+	if opts.FillMerged {
+		if err := r.loadMerged(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return r, nil
 }
 
-// ReadAll lee todos los registros del Excel
-func (r *ExcelReader) ReadAll() ([][]string, error) {
-	rows, err := r.file.GetRows(r.sheetName)
+// selectSheet resuelve la hoja y, si se indica, el rango a leer
+func (r *ExcelReader) selectSheet(sheet, rangeName string) error {
+	if rangeName == "" {
+		sheetName, err := resolveSheet(r.file.GetSheetList(), sheet)
+		if err != nil {
+			return err
+		}
+		r.sheetName = sheetName
+		return nil
+	}
+
+	sheetName, area, err := resolveRange(r.file, sheet, rangeName)
 	if err != nil {
-		return nil, fmt.Errorf("error leyendo hoja Excel '%s': %w", r.sheetName, err)
+		return err
+	}
+	if sheet != "" {
+		selected, err := resolveSheet(r.file.GetSheetList(), sheet)
+		if err != nil {
+			return err
+		}
+		if selected != sheetName {
+			return fmt.Errorf("el rango '%s' está en la hoja '%s', no en '%s'", rangeName, sheetName, selected)
+		}
 	}
 
-	if len(rows) < 2 {
-		return nil, fmt.Errorf("el archivo Excel debe tener al menos una cabecera y una fila de datos")
+	log.Printf("[INFO] Rango '%s': hoja '%s', filas %d a %d", rangeName, sheetName, area.firstRow, area.lastRow)
+	r.sheetName, r.area = sheetName, area
+	return nil
+}
+
+// resolveSheet busca una hoja por nombre exacto, luego sin distinguir
+// mayúsculas y por último como índice desde 1
+func resolveSheet(sheets []string, sheet string) (string, error) {
+	if len(sheets) == 0 {
+		return "", fmt.Errorf("no se encontraron hojas en el archivo Excel")
+	}
+
+	if sheet == "" {
+		return sheets[0], nil
 	}
 
-	// Normalizar filas (asegurar que todas tengan la misma longitud)
-	maxCols := 0
-	for _, row := range rows {
-		if len(row) > maxCols {
-			maxCols = len(row)
+	for _, name := range sheets {
+		if name == sheet {
+			return name, nil
 		}
 	}
+	for _, name := range sheets {
+		if strings.EqualFold(name, sheet) {
+			return name, nil
+		}
+	}
+	if index, err := strconv.Atoi(sheet); err == nil && index >= 1 && index <= len(sheets) {
+		return sheets[index-1], nil
+	}
 
-	normalizedRows := make([][]string, len(rows))
-	for i, row := range rows {
-		normalizedRows[i] = make([]string, maxCols)
-		copy(normalizedRows[i], row)
+	return "", fmt.Errorf("hoja '%s' no encontrada (hojas disponibles: %s)", sheet, strings.Join(sheets, ", "))
+}
+
+// ListSheets retorna los nombres de las hojas de un libro Excel u ODS en orden
+func ListSheets(filePath string) ([]string, error) {
+	if strings.EqualFold(filepath.Ext(filePath), ".ods") {
+		return ListODSSheets(filePath)
 	}
 
-	r.rows = normalizedRows
-	return normalizedRows, nil
+	if strings.EqualFold(filepath.Ext(filePath), ".xls") {
+		legacy, err := isCFB(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if legacy {
+			return listXLSSheets(filePath)
+		}
+	}
+
+	file, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo Excel: %w", err)
+	}
+	defer file.Close()
+
+	return file.GetSheetList(), nil
+}
+
+// FilterSheets aplica los patrones de inclusión y exclusión (sintaxis de
+// filepath.Match, sin distinguir mayúsculas) a una lista de hojas. Sin
+// patrones de inclusión se incluyen todas
+func FilterSheets(sheets, include, exclude []string) []string {
+	var selected []string
+	for _, sheet := range sheets {
+		if len(include) > 0 && !matchesAny(sheet, include) {
+			continue
+		}
+		if matchesAny(sheet, exclude) {
+			continue
+		}
+		selected = append(selected, sheet)
+	}
+	return selected
+}
+
+// matchesAny indica si el nombre coincide con alguno de los patrones
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Next lee la siguiente fila de la hoja
+func (r *ExcelReader) Next() (Row, error) {
+	if r.rows == nil {
+		rows, err := r.file.Rows(r.sheetName)
+		if err != nil {
+			return Row{}, fmt.Errorf("error leyendo hoja Excel '%s': %w", r.sheetName, err)
+		}
+		r.rows = rows
+	}
+
+	for {
+		if !r.rows.Next() {
+			if err := r.rows.Error(); err != nil {
+				return Row{}, fmt.Errorf("error leyendo hoja Excel '%s': %w", r.sheetName, err)
+			}
+			return Row{}, io.EOF
+		}
+		r.rowNum++
+
+		// Con un rango solo se entregan sus filas y columnas
+		if r.area != nil {
+			if r.rowNum > r.area.lastRow {
+				return Row{}, io.EOF
+			}
+			if !r.area.containsRow(r.rowNum) {
+				continue
+			}
+		}
+
+		values, err := r.rows.Columns()
+		if err != nil {
+			return Row{}, fmt.Errorf("error leyendo fila %d de la hoja '%s': %w", r.rowNum, r.sheetName, err)
+		}
+		if r.formulas {
+			if values, err = r.evaluateRow(values); err != nil {
+				return Row{}, err
+			}
+		}
+		if len(r.merged) > 0 {
+			values = r.fillMerged(values)
+		}
+		if r.area != nil {
+			values = r.area.slice(values)
+		}
+		return Row{Number: r.rowNum, Values: values}, nil
+	}
+}
+
+// ReadAll lee todos los registros del Excel
+func (r *ExcelReader) ReadAll() ([][]string, error) {
+	return readAll(r)
 }
 
 // Close cierra el archivo Excel
 func (r *ExcelReader) Close() error {
+	if r.rows != nil {
+		r.rows.Close()
+	}
 	if r.file != nil {
 		return r.file.Close()
 	}
 	return nil
 }
 
-// ReadData es una función de utilidad que lee datos de cualquier formato soportado
-func ReadData(filePath string) (headers []string, data [][]string, headerMap map[string]int, err error) {
-	reader, err := NewDataReader(filePath)
+// readAll lee todas las filas de un reader (cabecera incluida)
+func readAll(reader DataReader) ([][]string, error) {
+	var records [][]string
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, row.Values)
+	}
+
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%w: el archivo debe tener al menos una cabecera y una fila de datos", ErrNoData)
+	}
+
+	return records, nil
+}
+
+// OpenData abre un archivo de cualquier formato soportado y lee su cabecera.
+// header.Number es la fila donde se encontró la cabecera y header.Values sus
+// columnas (ya mapeadas). Las filas de datos se obtienen después con
+// reader.Next(); las filas vacías y de totales al final de los datos se omiten
+func OpenData(filePath string, opts ReadOptions) (reader DataReader, header Row, headerMap map[string]int, err error) {
+	reader, err = NewDataReader(filePath, opts)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, Row{}, nil, err
+	}
+
+	if csvReader, ok := reader.(*CSVReader); ok {
+		log.Printf("[INFO] Dialecto CSV: %s", csvReader.Dialect())
 	}
-	defer reader.Close()
 
-	records, err := reader.ReadAll()
+	// La cabecera es la primera fila con las columnas esperadas (o la primera
+	// fila si no se indican)
+	header, located, err := locateHeader(reader, opts)
 	if err != nil {
-		return nil, nil, nil, err
+		reader.Close()
+		if err == io.EOF {
+			return nil, Row{}, nil, fmt.Errorf("%w: el archivo no tiene cabecera", ErrNoData)
+		}
+		return nil, Row{}, nil, err
 	}
 
-	// Primera fila son las cabeceras
-	headers = records[0]
-	data = records[1:]
+	// Las filas vacías y de totales del final no son datos
+	reader = &trailerReader{DataReader: located}
+
+	if opts.Mapping != nil {
+		mapped, mappedHeaders, err := opts.Mapping.apply(reader, header.Values)
+		if err != nil {
+			reader.Close()
+			return nil, Row{}, nil, err
+		}
+		reader, header.Values = mapped, mappedHeaders
+	}
 
 	// Crear mapa de índices de cabeceras
 	headerMap = make(map[string]int)
-	for i, h := range headers {
+	for i, h := range header.Values {
 		headerMap[strings.TrimSpace(h)] = i
 	}
 
+	return reader, header, headerMap, nil
+}
+
+// ReadData es una función de utilidad que lee datos de cualquier formato soportado
+func ReadData(filePath string, opts ReadOptions) (headers []string, data [][]string, headerMap map[string]int, err error) {
+	reader, header, headerMap, err := OpenData(filePath, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer reader.Close()
+	headers = header.Values
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		data = append(data, row.Values)
+	}
+
+	if len(data) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: el archivo debe tener al menos una cabecera y una fila de datos", ErrNoData)
+	}
+
 	return headers, data, headerMap, nil
 }
 
@@ -176,7 +511,7 @@ func ValidateHeaders(headerMap map[string]int, requiredColumns []string) error {
 	}
 
 	if len(missing) > 0 {
-		return fmt.Errorf("columnas requeridas faltantes: %v", missing)
+		return fmt.Errorf("%w: %v", ErrMissingColumns, missing)
 	}
 
 	return nil
@@ -200,3 +535,35 @@ func GetCellValueOrDefault(row []string, headerMap map[string]int, columnName st
 	}
 	return defaultValue
 }
+
+// ReadXML decodifica un archivo XML en v. Además de UTF-8 acepta los
+// archivos declarados en UTF-16, Latin-1 o Windows-1252
+func ReadXML(filePath string, v interface{}) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("error abriendo archivo XML: %w", err)
+	}
+	defer file.Close()
+
+	if err := NewXMLDecoder(bufio.NewReader(file)).Decode(v); err != nil {
+		return fmt.Errorf("error decodificando XML '%s': %w", filePath, err)
+	}
+	return nil
+}
+
+// NewXMLDecoder crea un decodificador XML que acepta las mismas
+// codificaciones que ReadXML
+func NewXMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		enc, err := ParseEncoding(charset)
+		if err != nil {
+			return nil, err
+		}
+		if dec := decoderFor(enc); dec != nil {
+			return dec.NewDecoder().Reader(input), nil
+		}
+		return input, nil
+	}
+	return decoder
+}
@@ -0,0 +1,261 @@
+// pkg/fileio/records.go
+package fileio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSONReader implementa DataReader para listas de señales en JSON (arreglo
+// de objetos). La cabecera es la unión de las claves en orden de aparición;
+// se obtiene con una primera pasada y luego los objetos se leen uno a uno
+type JSONReader struct {
+	file    *os.File
+	decoder *json.Decoder
+	header  []string
+	rowNum  int
+}
+
+// YAMLReader implementa DataReader para listas de señales en YAML (secuencia
+// de mapas) con la misma semántica de cabecera que JSONReader
+type YAMLReader struct {
+	header  []string
+	index   map[string]int
+	objects []*yaml.Node
+	rowNum  int
+}
+
+// NewJSONReader crea un nuevo lector de JSON
+func NewJSONReader(filePath string) (*JSONReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo JSON: %w", err)
+	}
+
+	header, err := scanJSONKeys(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error leyendo JSON: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error leyendo JSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(file)
+	if _, err := decoder.Token(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error leyendo JSON: %w", err)
+	}
+
+	return &JSONReader{
+		file:    file,
+		decoder: decoder,
+		header:  header,
+	}, nil
+}
+
+// scanJSONKeys recorre el arreglo y retorna las claves de todos los objetos
+// en orden de aparición, sin conservar los valores
+func scanJSONKeys(r io.Reader) ([]string, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, fmt.Errorf("se esperaba un arreglo de objetos")
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for index := 0; decoder.More(); index++ {
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			return nil, fmt.Errorf("el elemento %d no es un objeto", index+1)
+		}
+
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := token.(string)
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// Next retorna la cabecera y luego un objeto por fila
+func (r *JSONReader) Next() (Row, error) {
+	if r.rowNum == 0 {
+		r.rowNum++
+		if len(r.header) == 0 {
+			return Row{}, io.EOF
+		}
+		return Row{Number: r.rowNum, Values: r.header}, nil
+	}
+
+	if !r.decoder.More() {
+		return Row{}, io.EOF
+	}
+	r.rowNum++
+
+	var object map[string]json.RawMessage
+	if err := r.decoder.Decode(&object); err != nil {
+		return Row{}, fmt.Errorf("error leyendo JSON (fila %d): %w", r.rowNum, err)
+	}
+
+	values := make([]string, len(r.header))
+	for i, key := range r.header {
+		raw, ok := object[key]
+		if !ok {
+			continue
+		}
+		value, err := jsonScalar(raw)
+		if err != nil {
+			return Row{}, fmt.Errorf("error leyendo JSON (fila %d, clave '%s'): %w", r.rowNum, key, err)
+		}
+		values[i] = value
+	}
+
+	return Row{Number: r.rowNum, Values: values}, nil
+}
+
+// jsonScalar convierte un valor JSON escalar en el texto de la celda
+func jsonScalar(raw json.RawMessage) (string, error) {
+	text := strings.TrimSpace(string(raw))
+	if text == "" || text == "null" {
+		return "", nil
+	}
+
+	switch text[0] {
+	case '"':
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", err
+		}
+		return value, nil
+	case '{', '[':
+		return "", fmt.Errorf("se esperaba un valor escalar")
+	default:
+		// Números y booleanos conservan su representación literal
+		return text, nil
+	}
+}
+
+// ReadAll lee todos los registros del JSON
+func (r *JSONReader) ReadAll() ([][]string, error) {
+	return readAll(r)
+}
+
+// Close cierra el archivo JSON
+func (r *JSONReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+// NewYAMLReader crea un nuevo lector de YAML. El documento se decodifica
+// completo porque YAML no admite lectura incremental por elemento
+func NewYAMLReader(filePath string) (*YAMLReader, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo YAML: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error leyendo YAML: %w", err)
+	}
+
+	r := &YAMLReader{index: make(map[string]int)}
+	if len(doc.Content) == 0 {
+		return r, nil
+	}
+
+	list := doc.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("error leyendo YAML: se esperaba una lista de objetos")
+	}
+
+	for i, object := range list.Content {
+		if object.Kind == yaml.AliasNode {
+			object = object.Alias
+		}
+		if object.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("error leyendo YAML: el elemento %d (línea %d) no es un objeto", i+1, object.Line)
+		}
+
+		for k := 0; k+1 < len(object.Content); k += 2 {
+			key := object.Content[k].Value
+			if _, seen := r.index[key]; !seen {
+				r.index[key] = len(r.header)
+				r.header = append(r.header, key)
+			}
+		}
+		r.objects = append(r.objects, object)
+	}
+
+	return r, nil
+}
+
+// Next retorna la cabecera y luego un objeto por fila
+func (r *YAMLReader) Next() (Row, error) {
+	if r.rowNum == 0 {
+		r.rowNum++
+		if len(r.header) == 0 {
+			return Row{}, io.EOF
+		}
+		return Row{Number: r.rowNum, Values: r.header}, nil
+	}
+
+	if r.rowNum-1 >= len(r.objects) {
+		return Row{}, io.EOF
+	}
+	object := r.objects[r.rowNum-1]
+	r.rowNum++
+
+	values := make([]string, len(r.header))
+	for k := 0; k+1 < len(object.Content); k += 2 {
+		key, node := object.Content[k].Value, object.Content[k+1]
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		if node.Kind != yaml.ScalarNode {
+			return Row{}, fmt.Errorf("error leyendo YAML (línea %d, clave '%s'): se esperaba un valor escalar", node.Line, key)
+		}
+		if node.Tag != "!!null" {
+			values[r.index[key]] = node.Value
+		}
+	}
+
+	return Row{Number: r.rowNum, Values: values}, nil
+}
+
+// ReadAll lee todos los registros del YAML
+func (r *YAMLReader) ReadAll() ([][]string, error) {
+	return readAll(r)
+}
+
+// Close libera los objetos leídos
+func (r *YAMLReader) Close() error {
+	r.objects = nil
+	return nil
+}
@@ -0,0 +1,314 @@
+// pkg/fileio/table.go
+package fileio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Formatos de salida tabular soportados
+const (
+	FormatCSV     = "csv"
+	FormatXLSX    = "xlsx"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// TableFormats lista los formatos aceptados por NewTableWriter
+var TableFormats = []string{FormatCSV, FormatXLSX, FormatJSONL, FormatParquet}
+
+// ColumnType indica cómo se representa una columna en formatos tipados
+type ColumnType int
+
+const (
+	TextColumn    ColumnType = iota // Texto
+	IntegerColumn                   // Entero de 64 bits
+	NumberColumn                    // Número decimal
+)
+
+// TableColumn describe una columna de la tabla de salida
+type TableColumn struct {
+	Name string
+	Type ColumnType
+}
+
+// Cell es un valor ya formateado. Null distingue los valores NULL para los
+// formatos que los representan de forma nativa (XLSX, JSON Lines, Parquet)
+type Cell struct {
+	Value string
+	Null  bool
+}
+
+// TableWriter define la interfaz común para escribir resultados tabulares
+type TableWriter interface {
+	WriteHeader(columns []TableColumn) error
+	WriteRow(cells []Cell) error
+	Close() error
+}
+
+// NewTableWriter crea el escritor del formato indicado
+func NewTableWriter(format, filePath string) (TableWriter, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		writer, err := NewCSVWriter(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &csvTableWriter{writer: writer}, nil
+	case FormatXLSX:
+		return NewXLSXTableWriter(filePath)
+	case FormatJSONL:
+		return NewJSONLTableWriter(filePath)
+	case FormatParquet:
+		return NewParquetTableWriter(filePath)
+	default:
+		return nil, fmt.Errorf("formato de salida no soportado: %s (use %s)", format, strings.Join(TableFormats, ", "))
+	}
+}
+
+// IsTableFormat indica si el formato de salida está soportado
+func IsTableFormat(format string) bool {
+	for _, f := range TableFormats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// csvTableWriter adapta CSVWriter a TableWriter
+type csvTableWriter struct {
+	writer *CSVWriter
+	record []string
+}
+
+func (w *csvTableWriter) WriteHeader(columns []TableColumn) error {
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Name
+	}
+	w.record = make([]string, len(columns))
+	return w.writer.WriteRow(headers)
+}
+
+func (w *csvTableWriter) WriteRow(cells []Cell) error {
+	for i, cell := range cells {
+		w.record[i] = cell.Value
+	}
+	return w.writer.WriteRow(w.record)
+}
+
+func (w *csvTableWriter) Close() error {
+	return w.writer.Close()
+}
+
+// XLSXTableWriter escribe un libro Excel en modo streaming, con la cabecera
+// resaltada e inmovilizada
+type XLSXTableWriter struct {
+	filePath string
+	file     *excelize.File
+	stream   *excelize.StreamWriter
+	columns  []TableColumn
+	row      int
+	values   []interface{}
+}
+
+// xlsxSheetName es el nombre de la hoja de resultados
+const xlsxSheetName = "Sheet1"
+
+// NewXLSXTableWriter crea un nuevo escritor de Excel
+func NewXLSXTableWriter(filePath string) (*XLSXTableWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error creando hoja Excel: %w", err)
+	}
+
+	return &XLSXTableWriter{
+		filePath: filePath,
+		file:     file,
+		stream:   stream,
+	}, nil
+}
+
+// WriteHeader escribe la cabecera con estilo y la inmoviliza
+func (w *XLSXTableWriter) WriteHeader(columns []TableColumn) error {
+	style, err := w.file.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill:   excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"1F4E78"}},
+		Border: []excelize.Border{{Type: "bottom", Color: "000000", Style: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creando estilo de cabecera: %w", err)
+	}
+
+	if err := w.stream.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return fmt.Errorf("error inmovilizando cabecera: %w", err)
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = excelize.Cell{StyleID: style, Value: col.Name}
+		width := float64(len(col.Name) + 4)
+		if width < 12 {
+			width = 12
+		}
+		if err := w.stream.SetColWidth(i+1, i+1, width); err != nil {
+			return fmt.Errorf("error ajustando columnas: %w", err)
+		}
+	}
+
+	w.columns = columns
+	w.values = make([]interface{}, len(columns))
+	w.row = 1
+	return w.stream.SetRow("A1", header)
+}
+
+// WriteRow escribe una fila; las columnas numéricas se escriben como números
+func (w *XLSXTableWriter) WriteRow(cells []Cell) error {
+	if w.row >= excelize.TotalRows {
+		return fmt.Errorf("el resultado supera el máximo de %d filas de Excel", excelize.TotalRows)
+	}
+	w.row++
+
+	for i, cell := range cells {
+		w.values[i] = xlsxValue(w.columns[i].Type, cell)
+	}
+
+	cellName, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cellName, w.values)
+}
+
+// Close guarda el libro. Se escribe con WriteTo para permitir rutas sin
+// extensión .xlsx (archivos .partial)
+func (w *XLSXTableWriter) Close() error {
+	defer w.file.Close()
+
+	if err := w.stream.Flush(); err != nil {
+		return fmt.Errorf("error finalizando hoja Excel: %w", err)
+	}
+
+	out, err := os.Create(w.filePath)
+	if err != nil {
+		return fmt.Errorf("error creando archivo Excel: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := w.file.WriteTo(out); err != nil {
+		return fmt.Errorf("error guardando archivo Excel: %w", err)
+	}
+	return out.Close()
+}
+
+// xlsxValue convierte una celda al valor nativo de Excel
+func xlsxValue(columnType ColumnType, cell Cell) interface{} {
+	if cell.Null {
+		return nil
+	}
+	if columnType != TextColumn {
+		if number, err := strconv.ParseFloat(cell.Value, 64); err == nil {
+			return number
+		}
+	}
+	return cell.Value
+}
+
+// JSONLTableWriter escribe un objeto JSON por fila (JSON Lines)
+type JSONLTableWriter struct {
+	file    *os.File
+	writer  *bufio.Writer
+	columns []TableColumn
+	keys    [][]byte
+	line    []byte
+}
+
+// NewJSONLTableWriter crea un nuevo escritor de JSON Lines
+func NewJSONLTableWriter(filePath string) (*JSONLTableWriter, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error creando archivo JSONL: %w", err)
+	}
+
+	return &JSONLTableWriter{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// WriteHeader registra las columnas; JSON Lines no tiene cabecera
+func (w *JSONLTableWriter) WriteHeader(columns []TableColumn) error {
+	w.columns = columns
+	w.keys = make([][]byte, len(columns))
+	for i, col := range columns {
+		key, err := json.Marshal(col.Name)
+		if err != nil {
+			return fmt.Errorf("error codificando columna '%s': %w", col.Name, err)
+		}
+		w.keys[i] = key
+	}
+	return nil
+}
+
+// WriteRow escribe una fila respetando el orden de las columnas. Los números
+// válidos se escriben sin comillas y los NULL como null
+func (w *JSONLTableWriter) WriteRow(cells []Cell) error {
+	line := append(w.line[:0], '{')
+	for i, cell := range cells {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		line = append(line, w.keys[i]...)
+		line = append(line, ':')
+
+		switch {
+		case cell.Null:
+			line = append(line, "null"...)
+		case w.columns[i].Type != TextColumn && isJSONNumber(cell.Value):
+			line = append(line, cell.Value...)
+		default:
+			value, err := json.Marshal(cell.Value)
+			if err != nil {
+				return err
+			}
+			line = append(line, value...)
+		}
+	}
+	line = append(line, '}', '\n')
+	w.line = line
+
+	_, err := w.writer.Write(line)
+	return err
+}
+
+// Close vacía el buffer y cierra el archivo
+func (w *JSONLTableWriter) Close() error {
+	if err := w.writer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("error escribiendo archivo JSONL: %w", err)
+	}
+	return w.file.Close()
+}
+
+// isJSONNumber indica si el texto es un número JSON válido
+func isJSONNumber(text string) bool {
+	if text == "" {
+		return false
+	}
+	var number json.Number
+	return json.Unmarshal([]byte(text), &number) == nil
+}
@@ -0,0 +1,751 @@
+// pkg/fileio/xls.go
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+	"github.com/xuri/excelize/v2"
+)
+
+// Tipos de registro BIFF8 utilizados
+const (
+	recFormula     = 0x0006
+	recEOF         = 0x000A
+	recFilePass    = 0x002F
+	recDateMode    = 0x0022
+	recContinue    = 0x003C
+	recBoundSheet  = 0x0085
+	recMulRK       = 0x00BD
+	recRString     = 0x00D6
+	recXF          = 0x00E0
+	recMergedCells = 0x00E5
+	recSST         = 0x00FC
+	recLabelSST    = 0x00FD
+	recNumber      = 0x0203
+	recLabel       = 0x0204
+	recBoolErr     = 0x0205
+	recString      = 0x0207
+	recRK          = 0x027E
+	recFormat      = 0x041E
+	recBOF         = 0x0809
+)
+
+const (
+	// biff8Version es la versión del registro BOF en archivos Excel 97-2003
+	biff8Version = 0x0600
+
+	// firstCustomNumFmt es el primer identificador de formato no predefinido
+	firstCustomNumFmt = 164
+)
+
+// cfbSignature identifica un archivo Compound File Binary (OLE2)
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// ErrEncrypted indica que el libro .xls está protegido con contraseña
+var ErrEncrypted = errors.New("el libro .xls está cifrado")
+
+// xlsErrorCodes traduce los códigos de error de celda BIFF8
+var xlsErrorCodes = map[byte]string{
+	0x00: "#NULL!",
+	0x07: "#DIV/0!",
+	0x0F: "#VALUE!",
+	0x17: "#REF!",
+	0x1D: "#NAME?",
+	0x24: "#NUM!",
+	0x2A: "#N/A",
+}
+
+// XLSReader implementa DataReader para libros Excel 97-2003 (BIFF8). Una
+// hoja .xls tiene como máximo 65536 filas, por lo que se decodifica completa
+// al abrirla y Next recorre las filas ya convertidas
+type XLSReader struct {
+	sheetName string
+	rows      [][]string
+	next      int
+}
+
+// xlsSheet es una hoja declarada en el libro
+type xlsSheet struct {
+	Name   string
+	Offset uint32 // Posición del BOF de la hoja en el stream Workbook
+}
+
+// xlsWorkbook contiene los datos globales de un libro BIFF8
+type xlsWorkbook struct {
+	stream   []byte
+	sheets   []xlsSheet
+	sst      []string
+	xfNumFmt []uint16
+	formats  map[uint16]string
+	date1904 bool
+}
+
+// xlsRecord es un registro BIFF con sus registros CONTINUE
+type xlsRecord struct {
+	Type      uint16
+	Data      []byte
+	Continues [][]byte
+}
+
+// NewXLSReader crea un lector para la hoja indicada (nombre o índice desde 1)
+// de un libro .xls. Sin hoja se lee la primera. Las fórmulas usan el
+// resultado guardado en el libro; fillMerged copia el valor de cada rango
+// combinado en todas sus celdas
+func NewXLSReader(filePath, sheet string, fillMerged bool) (*XLSReader, error) {
+	wb, err := openXLS(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetName, err := resolveSheet(wb.sheetNames(), sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	var offset uint32
+	for _, s := range wb.sheets {
+		if s.Name == sheetName {
+			offset = s.Offset
+			break
+		}
+	}
+
+	rows, err := wb.readSheet(offset, fillMerged)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo hoja Excel '%s': %w", sheetName, err)
+	}
+
+	return &XLSReader{
+		sheetName: sheetName,
+		rows:      rows,
+	}, nil
+}
+
+// Next retorna la siguiente fila de la hoja
+func (r *XLSReader) Next() (Row, error) {
+	if r.next >= len(r.rows) {
+		return Row{}, io.EOF
+	}
+	r.next++
+	return Row{Number: r.next, Values: r.rows[r.next-1]}, nil
+}
+
+// ReadAll lee todos los registros de la hoja
+func (r *XLSReader) ReadAll() ([][]string, error) {
+	return readAll(r)
+}
+
+// Close libera las filas de la hoja
+func (r *XLSReader) Close() error {
+	r.rows = nil
+	return nil
+}
+
+// isCFB indica si el archivo es un Compound File Binary (.xls real) y no un
+// libro OOXML con extensión .xls
+func isCFB(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, fmt.Errorf("error abriendo archivo Excel: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, len(cfbSignature))
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false, nil
+	}
+	return bytes.Equal(header, cfbSignature), nil
+}
+
+// listXLSSheets retorna los nombres de las hojas de un libro .xls
+func listXLSSheets(filePath string) ([]string, error) {
+	wb, err := openXLS(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return wb.sheetNames(), nil
+}
+
+// openXLS lee el stream Workbook y los registros globales del libro
+func openXLS(filePath string) (*xlsWorkbook, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo Excel: %w", err)
+	}
+	defer file.Close()
+
+	doc, err := mscfb.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo Excel: %w", err)
+	}
+
+	var stream []byte
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		switch entry.Name {
+		case "Workbook":
+			stream, err = io.ReadAll(entry)
+			if err != nil {
+				return nil, fmt.Errorf("error leyendo stream Workbook: %w", err)
+			}
+		case "Book":
+			return nil, fmt.Errorf("error abriendo archivo Excel: formato Excel 5.0/95 (BIFF5) no soportado, guarde el libro como .xlsx")
+		}
+		if stream != nil {
+			break
+		}
+	}
+	if stream == nil {
+		return nil, fmt.Errorf("error abriendo archivo Excel: el archivo no contiene un libro de Excel")
+	}
+
+	wb := &xlsWorkbook{
+		stream:  stream,
+		formats: make(map[uint16]string),
+	}
+	if err := wb.readGlobals(); err != nil {
+		return nil, fmt.Errorf("error abriendo archivo Excel: %w", err)
+	}
+	return wb, nil
+}
+
+// sheetNames retorna los nombres de las hojas en orden
+func (wb *xlsWorkbook) sheetNames() []string {
+	names := make([]string, len(wb.sheets))
+	for i, s := range wb.sheets {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// readGlobals procesa el substream de datos globales del libro
+func (wb *xlsWorkbook) readGlobals() error {
+	records := newRecordIterator(wb.stream, 0)
+
+	bof, err := records.next()
+	if err != nil {
+		return err
+	}
+	if bof.Type != recBOF || len(bof.Data) < 2 || binary.LittleEndian.Uint16(bof.Data) != biff8Version {
+		return fmt.Errorf("versión BIFF no soportada (se requiere Excel 97-2003)")
+	}
+
+	for {
+		rec, err := records.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch rec.Type {
+		case recEOF:
+			return nil
+		case recFilePass:
+			return ErrEncrypted
+		case recDateMode:
+			wb.date1904 = len(rec.Data) >= 2 && binary.LittleEndian.Uint16(rec.Data) == 1
+		case recFormat:
+			if len(rec.Data) < 2 {
+				continue
+			}
+			code, err := newContinueReader(rec.Data[2:], nil).unicodeString(2)
+			if err != nil {
+				return fmt.Errorf("registro FORMAT inválido: %w", err)
+			}
+			wb.formats[binary.LittleEndian.Uint16(rec.Data)] = code
+		case recXF:
+			if len(rec.Data) < 4 {
+				continue
+			}
+			wb.xfNumFmt = append(wb.xfNumFmt, binary.LittleEndian.Uint16(rec.Data[2:]))
+		case recBoundSheet:
+			if len(rec.Data) < 6 {
+				continue
+			}
+			// Solo hojas de cálculo; se omiten gráficos y macros
+			if rec.Data[5] != 0x00 {
+				continue
+			}
+			name, err := newContinueReader(rec.Data[6:], nil).unicodeString(1)
+			if err != nil {
+				return fmt.Errorf("registro BOUNDSHEET inválido: %w", err)
+			}
+			wb.sheets = append(wb.sheets, xlsSheet{
+				Name:   name,
+				Offset: binary.LittleEndian.Uint32(rec.Data),
+			})
+		case recSST:
+			if err := wb.readSST(rec); err != nil {
+				return fmt.Errorf("tabla de strings compartidos inválida: %w", err)
+			}
+		}
+	}
+}
+
+// readSST decodifica la tabla de strings compartidos
+func (wb *xlsWorkbook) readSST(rec xlsRecord) error {
+	if len(rec.Data) < 8 {
+		return io.ErrUnexpectedEOF
+	}
+	count := binary.LittleEndian.Uint32(rec.Data[4:])
+
+	r := newContinueReader(rec.Data[8:], rec.Continues)
+	wb.sst = make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		s, err := r.unicodeString(2)
+		if err != nil {
+			return err
+		}
+		wb.sst = append(wb.sst, s)
+	}
+	return nil
+}
+
+// readSheet decodifica las celdas de la hoja que comienza en offset. Las
+// celdas combinadas conservan el valor solo en la celda superior izquierda,
+// igual que al leer un .xlsx, salvo con fillMerged
+func (wb *xlsWorkbook) readSheet(offset uint32, fillMerged bool) ([][]string, error) {
+	if int(offset) >= len(wb.stream) {
+		return nil, fmt.Errorf("posición de hoja fuera del stream")
+	}
+
+	formatter, err := newXLSNumberFormatter(wb)
+	if err != nil {
+		return nil, err
+	}
+	defer formatter.Close()
+
+	var (
+		rows    [][]string
+		merged  [][4]uint16 // rwFirst, rwLast, colFirst, colLast
+		pending = -1        // Fila de la fórmula cuyo resultado viene en STRING
+		pendCol uint16
+		depth   int
+	)
+
+	set := func(row, col uint16, value string) {
+		for int(row) >= len(rows) {
+			rows = append(rows, nil)
+		}
+		for int(col) >= len(rows[row]) {
+			rows[row] = append(rows[row], "")
+		}
+		rows[row][col] = value
+	}
+
+	number := func(row, col, xf uint16, value float64) error {
+		text, err := formatter.format(xf, value)
+		if err != nil {
+			return err
+		}
+		set(row, col, text)
+		return nil
+	}
+
+	records := newRecordIterator(wb.stream, int(offset))
+	for {
+		rec, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Los substreams anidados (gráficos incrustados) se ignoran
+		switch rec.Type {
+		case recBOF:
+			depth++
+			continue
+		case recEOF:
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+		if depth > 1 {
+			continue
+		}
+
+		data := rec.Data
+		if len(data) < 6 && rec.Type != recMergedCells && rec.Type != recString {
+			continue
+		}
+
+		switch rec.Type {
+		case recLabelSST:
+			if len(data) < 10 {
+				continue
+			}
+			index := binary.LittleEndian.Uint32(data[6:])
+			if int(index) < len(wb.sst) {
+				set(le16(data), le16(data[2:]), wb.sst[index])
+			}
+		case recLabel, recRString:
+			text, err := newContinueReader(data[6:], rec.Continues).unicodeString(2)
+			if err != nil {
+				return nil, fmt.Errorf("registro LABEL inválido: %w", err)
+			}
+			set(le16(data), le16(data[2:]), text)
+		case recNumber:
+			if len(data) < 14 {
+				continue
+			}
+			value := math.Float64frombits(binary.LittleEndian.Uint64(data[6:]))
+			if err := number(le16(data), le16(data[2:]), le16(data[4:]), value); err != nil {
+				return nil, err
+			}
+		case recRK:
+			if len(data) < 10 {
+				continue
+			}
+			value := decodeRK(binary.LittleEndian.Uint32(data[6:]))
+			if err := number(le16(data), le16(data[2:]), le16(data[4:]), value); err != nil {
+				return nil, err
+			}
+		case recMulRK:
+			row, col := le16(data), le16(data[2:])
+			for p := 4; p+6 <= len(data)-2; p += 6 {
+				value := decodeRK(binary.LittleEndian.Uint32(data[p+2:]))
+				if err := number(row, col, le16(data[p:]), value); err != nil {
+					return nil, err
+				}
+				col++
+			}
+		case recBoolErr:
+			if len(data) < 8 {
+				continue
+			}
+			set(le16(data), le16(data[2:]), boolErrValue(data[6], data[7] == 1))
+		case recFormula:
+			if len(data) < 14 {
+				continue
+			}
+			row, col, xf := le16(data), le16(data[2:]), le16(data[4:])
+			result := data[6:14]
+			if result[6] != 0xFF || result[7] != 0xFF {
+				value := math.Float64frombits(binary.LittleEndian.Uint64(result))
+				if err := number(row, col, xf, value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			switch result[0] {
+			case 0x00: // String: el valor llega en el registro STRING siguiente
+				pending, pendCol = int(row), col
+			case 0x01:
+				set(row, col, boolErrValue(result[2], false))
+			case 0x02:
+				set(row, col, boolErrValue(result[2], true))
+			}
+		case recString:
+			if pending < 0 {
+				continue
+			}
+			text, err := newContinueReader(data, rec.Continues).unicodeString(2)
+			if err != nil {
+				return nil, fmt.Errorf("registro STRING inválido: %w", err)
+			}
+			set(uint16(pending), pendCol, text)
+			pending = -1
+		case recMergedCells:
+			if len(data) < 2 {
+				continue
+			}
+			count := int(le16(data))
+			for i := 0; i < count && 2+8*(i+1) <= len(data); i++ {
+				p := 2 + 8*i
+				merged = append(merged, [4]uint16{le16(data[p:]), le16(data[p+2:]), le16(data[p+4:]), le16(data[p+6:])})
+			}
+		}
+	}
+
+	// Solo la celda superior izquierda de un rango combinado tiene valor; con
+	// fillMerged ese valor se copia en todo el rango
+	for _, m := range merged {
+		value := ""
+		if fillMerged && int(m[0]) < len(rows) && int(m[2]) < len(rows[m[0]]) {
+			value = rows[m[0]][m[2]]
+		}
+		for row := int(m[0]); row <= int(m[1]) && row < len(rows); row++ {
+			for col := int(m[2]); col <= int(m[3]); col++ {
+				if row == int(m[0]) && col == int(m[2]) {
+					continue
+				}
+				if value != "" {
+					set(uint16(row), uint16(col), value)
+				} else if col < len(rows[row]) {
+					rows[row][col] = ""
+				}
+			}
+		}
+	}
+
+	// Quitar las celdas vacías al final de cada fila, como excelize
+	for i, row := range rows {
+		end := len(row)
+		for end > 0 && row[end-1] == "" {
+			end--
+		}
+		rows[i] = row[:end]
+	}
+
+	return rows, nil
+}
+
+// le16 lee un entero little-endian de 16 bits
+func le16(b []byte) uint16 {
+	return binary.LittleEndian.Uint16(b)
+}
+
+// decodeRK decodifica un número en formato RK
+func decodeRK(rk uint32) float64 {
+	var value float64
+	if rk&0x02 != 0 {
+		value = float64(int32(rk) >> 2)
+	} else {
+		value = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		value /= 100
+	}
+	return value
+}
+
+// boolErrValue representa un booleano o un error de celda como en .xlsx
+func boolErrValue(value byte, isError bool) string {
+	if isError {
+		if code, ok := xlsErrorCodes[value]; ok {
+			return code
+		}
+		return "#N/A"
+	}
+	if value != 0 {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// recordIterator recorre los registros BIFF agrupando los CONTINUE
+type recordIterator struct {
+	stream []byte
+	pos    int
+}
+
+// newRecordIterator crea un iterador desde la posición indicada
+func newRecordIterator(stream []byte, pos int) *recordIterator {
+	return &recordIterator{stream: stream, pos: pos}
+}
+
+// next retorna el siguiente registro con sus CONTINUE, o io.EOF
+func (it *recordIterator) next() (xlsRecord, error) {
+	rec, err := it.raw()
+	if err != nil {
+		return xlsRecord{}, err
+	}
+
+	for it.pos+4 <= len(it.stream) && binary.LittleEndian.Uint16(it.stream[it.pos:]) == recContinue {
+		cont, err := it.raw()
+		if err != nil {
+			return xlsRecord{}, err
+		}
+		rec.Continues = append(rec.Continues, cont.Data)
+	}
+	return rec, nil
+}
+
+// raw lee un único registro
+func (it *recordIterator) raw() (xlsRecord, error) {
+	if it.pos+4 > len(it.stream) {
+		return xlsRecord{}, io.EOF
+	}
+	typ := binary.LittleEndian.Uint16(it.stream[it.pos:])
+	size := int(binary.LittleEndian.Uint16(it.stream[it.pos+2:]))
+	start := it.pos + 4
+	if start+size > len(it.stream) {
+		return xlsRecord{}, fmt.Errorf("registro 0x%04X truncado", typ)
+	}
+	it.pos = start + size
+	return xlsRecord{Type: typ, Data: it.stream[start : start+size]}, nil
+}
+
+// continueReader lee datos que pueden continuar en registros CONTINUE. Al
+// cortarse los caracteres de un string, el registro siguiente comienza con
+// un byte de opciones que indica si el resto está comprimido
+type continueReader struct {
+	cur  []byte
+	rest [][]byte
+}
+
+// newContinueReader crea un lector sobre data y sus continuaciones
+func newContinueReader(data []byte, continues [][]byte) *continueReader {
+	return &continueReader{cur: data, rest: continues}
+}
+
+// advance pasa al siguiente registro CONTINUE
+func (r *continueReader) advance() error {
+	if len(r.rest) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	r.cur, r.rest = r.rest[0], r.rest[1:]
+	return nil
+}
+
+// bytes lee n bytes que pueden cruzar registros
+func (r *continueReader) bytes(n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		if len(r.cur) == 0 {
+			if err := r.advance(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		take := min(n-len(out), len(r.cur))
+		out = append(out, r.cur[:take]...)
+		r.cur = r.cur[take:]
+	}
+	return out, nil
+}
+
+// unicodeString lee un XLUnicodeRichExtendedString cuyo largo ocupa
+// lenSize bytes (1 o 2)
+func (r *continueReader) unicodeString(lenSize int) (string, error) {
+	header, err := r.bytes(lenSize + 1)
+	if err != nil {
+		return "", err
+	}
+	count := int(header[0])
+	if lenSize == 2 {
+		count = int(binary.LittleEndian.Uint16(header))
+	}
+	flags := header[lenSize]
+
+	runs, extSize := 0, 0
+	if flags&0x08 != 0 {
+		b, err := r.bytes(2)
+		if err != nil {
+			return "", err
+		}
+		runs = int(binary.LittleEndian.Uint16(b))
+	}
+	if flags&0x04 != 0 {
+		b, err := r.bytes(4)
+		if err != nil {
+			return "", err
+		}
+		extSize = int(binary.LittleEndian.Uint32(b))
+	}
+
+	units := make([]uint16, 0, count)
+	highByte := flags&0x01 != 0
+	for len(units) < count {
+		if len(r.cur) == 0 {
+			if err := r.advance(); err != nil {
+				return "", err
+			}
+			if len(r.cur) == 0 {
+				continue
+			}
+			highByte = r.cur[0]&0x01 != 0
+			r.cur = r.cur[1:]
+			continue
+		}
+		if highByte {
+			if len(r.cur) < 2 {
+				return "", io.ErrUnexpectedEOF
+			}
+			units = append(units, binary.LittleEndian.Uint16(r.cur))
+			r.cur = r.cur[2:]
+		} else {
+			units = append(units, uint16(r.cur[0]))
+			r.cur = r.cur[1:]
+		}
+	}
+
+	// Saltar formato de texto enriquecido y datos extendidos (fonética)
+	if _, err := r.bytes(4*runs + extSize); err != nil {
+		return "", err
+	}
+
+	return string(utf16.Decode(units)), nil
+}
+
+// xlsNumberFormatter aplica los formatos numéricos del libro con el mismo
+// motor que excelize usa al leer .xlsx, de modo que fechas y números se
+// representen igual en ambos formatos
+type xlsNumberFormatter struct {
+	wb     *xlsWorkbook
+	file   *excelize.File
+	styles map[uint16]int // Formato numérico -> estilo en el libro auxiliar
+}
+
+// newXLSNumberFormatter crea el libro auxiliar con el sistema de fechas del libro
+func newXLSNumberFormatter(wb *xlsWorkbook) (*xlsNumberFormatter, error) {
+	file := excelize.NewFile()
+	date1904 := wb.date1904
+	if err := file.SetWorkbookProps(&excelize.WorkbookPropsOptions{Date1904: &date1904}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsNumberFormatter{
+		wb:     wb,
+		file:   file,
+		styles: make(map[uint16]int),
+	}, nil
+}
+
+// format retorna el texto de un número con el formato del XF indicado
+func (f *xlsNumberFormatter) format(xf uint16, value float64) (string, error) {
+	var numFmt uint16
+	if int(xf) < len(f.wb.xfNumFmt) {
+		numFmt = f.wb.xfNumFmt[xf]
+	}
+
+	style, ok := f.styles[numFmt]
+	if !ok {
+		var err error
+		if style, err = f.newStyle(numFmt); err != nil {
+			return "", err
+		}
+		f.styles[numFmt] = style
+	}
+
+	sheet := f.file.GetSheetName(0)
+	if err := f.file.SetCellFloat(sheet, "A1", value, -1, 64); err != nil {
+		return "", err
+	}
+	if err := f.file.SetCellStyle(sheet, "A1", "A1", style); err != nil {
+		return "", err
+	}
+	return f.file.GetCellValue(sheet, "A1")
+}
+
+// newStyle registra un estilo con el formato numérico indicado. Los formatos
+// predefinidos se usan por identificador, como en .xlsx
+func (f *xlsNumberFormatter) newStyle(numFmt uint16) (int, error) {
+	if numFmt == 0 {
+		return 0, nil
+	}
+	if code, ok := f.wb.formats[numFmt]; ok && numFmt >= firstCustomNumFmt {
+		return f.file.NewStyle(&excelize.Style{CustomNumFmt: &code})
+	}
+	if numFmt < firstCustomNumFmt {
+		return f.file.NewStyle(&excelize.Style{NumFmt: int(numFmt)})
+	}
+	return 0, nil
+}
+
+// Close libera el libro auxiliar
+func (f *xlsNumberFormatter) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,213 @@
+// pkg/xmlcreator/baseline.go
+package xmlcreator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"goScadaSur/pkg/config"
+	"goScadaSur/pkg/fileio"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Baseline son los XDF de una entrega anterior. Con una línea base cada
+// archivo generado contiene solo los elementos nuevos o modificados, y los
+// eliminados se escriben en un archivo de borrado aparte
+type Baseline struct {
+	dir     string
+	file    string          // Si se indicó un solo archivo
+	matched map[string]bool // Archivos de la línea base generados en esta ejecución
+}
+
+// LoadBaseline prepara la línea base desde un directorio (los XDF se buscan
+// con el mismo nombre que los generados) o un único archivo XDF. La línea base
+// no puede estar en el directorio de salida, que se sobrescribe
+func LoadBaseline(path string) (*Baseline, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("línea base: %w", err)
+	}
+
+	baseline := &Baseline{dir: path, matched: make(map[string]bool)}
+	if !info.IsDir() {
+		baseline.dir, baseline.file = filepath.Dir(path), filepath.Base(path)
+	}
+
+	dir, err := filepath.Abs(baseline.dir)
+	if err != nil {
+		return nil, err
+	}
+	outputDir, err := filepath.Abs(config.Global.Files.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+	if dir == outputDir {
+		return nil, fmt.Errorf("la línea base '%s' está en el directorio de salida; cópiela a otro directorio", path)
+	}
+
+	return baseline, nil
+}
+
+// lookup retorna el XDF de la línea base para un archivo generado y lo
+// registra como presente en la entrada
+func (b *Baseline) lookup(fileName string) (string, bool) {
+	b.matched[fileName] = true
+	if b.file != "" && b.file != fileName {
+		return "", false
+	}
+	path := filepath.Join(b.dir, fileName)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// saveRemoved escribe el archivo de borrado de cada XDF de la línea base
+// cuya estación no estaba en la entrada, con todos sus elementos. Se llama
+// después de procesar todos los datasets. Retorna los archivos escritos
+func (b *Baseline) saveRemoved(opts Options) ([]string, error) {
+	names := []string{b.file}
+	if b.file == "" {
+		entries, err := os.ReadDir(b.dir)
+		if err != nil {
+			return nil, fmt.Errorf("línea base: %w", err)
+		}
+		names = names[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+
+	suffixes := config.Global.Output.Suffixes
+	var files []string
+	for _, name := range names {
+		if b.matched[name] {
+			continue
+		}
+		for _, kind := range []string{"ifs", "imm"} {
+			if strings.HasSuffix(name, suffixes[kind+"_delete"]) || !strings.HasSuffix(name, suffixes[kind]) {
+				continue
+			}
+			fileBase := strings.TrimSuffix(name, suffixes[kind])
+			log.Printf("[INFO] %s: la estación no está en la entrada; sus elementos se eliminan", name)
+			written, err := saveDelta(name, fileBase+suffixes[kind+"_delete"], nil, filepath.Join(b.dir, name), opts)
+			files = append(files, written...)
+			if err != nil {
+				return files, err
+			}
+			break
+		}
+	}
+	return files, nil
+}
+
+// deltaResult son los Parent de un archivo delta y de su archivo de borrado
+type deltaResult struct {
+	Changed   []Parent
+	Removed   []Parent
+	Added     int
+	Modified  int
+	Unchanged int
+	Deleted   int
+}
+
+// computeDelta compara los Parent generados con el XDF de la línea base con
+// la misma identidad que xdf-diff (Path del Parent, tipo y Name)
+func computeDelta(parents []Parent, baselinePath string) (*deltaResult, error) {
+	// Los elementos generados se leen igual que un archivo para compararlos
+	data, err := xml.Marshal(XDF{Instances: Instances{Parents: parents}})
+	if err != nil {
+		return nil, fmt.Errorf("error codificando XML: %w", err)
+	}
+	var generated rawXDF
+	if err := xml.Unmarshal(data, &generated); err != nil {
+		return nil, fmt.Errorf("error decodificando XML: %w", err)
+	}
+
+	var baseline rawXDF
+	if err := fileio.ReadXML(baselinePath, &baseline); err != nil {
+		return nil, err
+	}
+	baselineElements := indexDiffElements(baseline)
+	byKey := make(map[string]*diffElement, len(baselineElements))
+	for _, element := range baselineElements {
+		byKey[element.key] = element
+	}
+
+	// Los elementos decodificados conservan el orden de los generados
+	result := &deltaResult{}
+	current := indexDiffElements(generated)
+	present := make(map[string]bool, len(current))
+	i := 0
+	for _, parent := range parents {
+		var elements []any
+		for _, element := range parent.Elements {
+			generatedElement := current[i]
+			i++
+			present[generatedElement.key] = true
+
+			old, exists := byKey[generatedElement.key]
+			switch {
+			case !exists:
+				result.Added++
+			case len(diffAttributes(old.attrs, generatedElement.attrs)) > 0:
+				result.Modified++
+			default:
+				result.Unchanged++
+				continue
+			}
+			elements = append(elements, element)
+		}
+		if len(elements) > 0 {
+			result.Changed = append(result.Changed, Parent{Path: parent.Path, Elements: elements})
+		}
+	}
+
+	// Los eliminados conservan el orden y el contenido de la línea base
+	for _, element := range baselineElements {
+		if present[element.key] {
+			continue
+		}
+		result.Deleted++
+		if n := len(result.Removed); n > 0 && result.Removed[n-1].Path == element.parentPath {
+			result.Removed[n-1].Elements = append(result.Removed[n-1].Elements, element.node)
+			continue
+		}
+		result.Removed = append(result.Removed, Parent{Path: element.parentPath, Elements: []any{element.node}})
+	}
+
+	return result, nil
+}
+
+// saveDelta escribe el delta de un archivo respecto de su XDF en la línea
+// base y el archivo de borrado. Retorna los archivos escritos
+func saveDelta(fileName, deleteFileName string, parents []Parent, baselinePath string, opts Options) ([]string, error) {
+	delta, err := computeDelta(parents, baselinePath)
+	if err != nil {
+		return nil, fmt.Errorf("comparando con la línea base '%s': %w", baselinePath, err)
+	}
+	log.Printf("[INFO] %s: %d nuevos, %d modificados, %d sin cambios, %d eliminados (línea base %s)",
+		fileName, delta.Added, delta.Modified, delta.Unchanged, delta.Deleted, baselinePath)
+
+	var files []string
+	for _, out := range []struct {
+		name    string
+		parents []Parent
+	}{
+		{fileName, delta.Changed},
+		{deleteFileName, delta.Removed},
+	} {
+		written, err := createAndSaveXML(out.name, out.parents, opts)
+		if err != nil {
+			return files, err
+		}
+		if written != "" {
+			files = append(files, written)
+		}
+	}
+	return files, nil
+}
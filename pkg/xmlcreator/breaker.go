@@ -0,0 +1,216 @@
+// pkg/xmlcreator/breaker.go
+package xmlcreator
+
+import (
+	"fmt"
+	"log"
+)
+
+// defaultTerminalName se usa cuando la plantilla del breaker no define terminales
+const defaultTerminalName = "T1"
+
+// breakerMeasurements son las mediciones que se enlazan al terminal del breaker
+var breakerMeasurements = map[string]bool{
+	"P":    true,
+	"Q":    true,
+	"I_S":  true,
+	"U_RS": true,
+}
+
+// bayBreaker representa un interruptor encontrado en una bahía
+type bayBreaker struct {
+	Name       string
+	ParentPath string
+	RowNum     int
+	Template   *Breaker
+}
+
+// bayMeasurement representa una medición candidata a enlazarse con el breaker
+type bayMeasurement struct {
+	Path     string
+	RowNum   int
+	Terminal string
+}
+
+// bayData acumula los breakers y mediciones de una bahía
+type bayData struct {
+	Path         string
+	Breakers     []bayBreaker
+	Measurements []bayMeasurement
+}
+
+// bayCollector agrupa breakers y mediciones por bahía conservando el orden
+type bayCollector struct {
+	naming *NamingRules
+	bays   []*bayData
+	index  map[string]*bayData
+}
+
+// newBayCollector crea un colector de bahías vacío
+func newBayCollector(naming *NamingRules) *bayCollector {
+	return &bayCollector{
+		naming: naming,
+		index:  make(map[string]*bayData),
+	}
+}
+
+// bay obtiene (o crea) la bahía con el path indicado
+func (c *bayCollector) bay(path string) *bayData {
+	bay, exists := c.index[path]
+	if !exists {
+		bay = &bayData{Path: path}
+		c.index[path] = bay
+		c.bays = append(c.bays, bay)
+	}
+	return bay
+}
+
+// collect registra la fila si corresponde a un breaker (CB o una plantilla
+// con Breaker) o a una medición enlazable. La bahía se identifica por el path
+// IMM de la fila (ImmPath) y los terminales se toman de la plantilla de la fila
+func (c *bayCollector) collect(elementKey string, rowNum int, data NamingData) error {
+	bayPath := data["ImmPath"]
+	displayName := data["DisplayName"]
+
+	if isBreakerElement(elementKey) {
+		var breakerTemplate *Breaker
+		if template, found := GetTemplate(elementKey); found {
+			breakerTemplate = template.Breaker
+		}
+
+		data["Breaker"] = displayName
+		parentPath, err := c.naming.BreakerParentPath(data)
+		if err != nil {
+			return err
+		}
+
+		bay := c.bay(bayPath)
+		bay.Breakers = append(bay.Breakers, bayBreaker{
+			Name:       displayName,
+			ParentPath: parentPath,
+			RowNum:     rowNum,
+			Template:   breakerTemplate,
+		})
+		return nil
+	}
+
+	if breakerMeasurements[elementKey] {
+		bay := c.bay(bayPath)
+		bay.Measurements = append(bay.Measurements, bayMeasurement{
+			Path:     fmt.Sprintf("%s/%s", bayPath, displayName),
+			RowNum:   rowNum,
+			Terminal: data["TERMINAL"],
+		})
+	}
+
+	return nil
+}
+
+// buildLinks genera un Parent por breaker con sus terminales y mediciones enlazadas
+func (c *bayCollector) buildLinks() []*ElementGroup {
+	var groups []*ElementGroup
+
+	for _, bay := range c.bays {
+		if len(bay.Breakers) == 0 {
+			continue
+		}
+
+		if len(bay.Breakers) > 1 {
+			log.Printf("[WARN] La bahía '%s' tiene %d breakers; use la columna BAY para separarlos",
+				bay.Path, len(bay.Breakers))
+		}
+
+		for _, breaker := range bay.Breakers {
+			terminals := createBreakerLinks(breaker, bay.Measurements)
+			if len(terminals) == 0 {
+				continue
+			}
+
+			groups = append(groups, &ElementGroup{
+				ParentPath: breaker.ParentPath,
+				Elements:   terminals,
+			})
+		}
+	}
+
+	return groups
+}
+
+// createBreakerLinks crea los terminales del breaker con sus enlaces de medición
+func createBreakerLinks(breaker bayBreaker, measurements []bayMeasurement) []any {
+	defaultTerminal := selectDefaultTerminal(breaker.Template)
+
+	var terminals []*LinkedTerminal
+	byName := make(map[string]*LinkedTerminal)
+	seen := make(map[string]bool)
+
+	for _, m := range measurements {
+		terminalName := defaultTerminal
+		if m.Terminal != "" {
+			if hasTerminal(breaker.Template, m.Terminal) {
+				terminalName = m.Terminal
+			} else {
+				log.Printf("[WARN] Fila %d: terminal '%s' no existe en el breaker '%s', usando '%s'",
+					m.RowNum, m.Terminal, breaker.Name, defaultTerminal)
+			}
+		}
+
+		// Evitar enlaces duplicados cuando una medición aparece en varias filas
+		if seen[m.Path] {
+			continue
+		}
+		seen[m.Path] = true
+
+		terminal, exists := byName[terminalName]
+		if !exists {
+			terminal = &LinkedTerminal{Name: terminalName}
+			byName[terminalName] = terminal
+			terminals = append(terminals, terminal)
+		}
+		terminal.Links = append(terminal.Links, Link_TerminalMeasuredByMeasurement{
+			PathB: m.Path,
+		})
+	}
+
+	elements := make([]any, 0, len(terminals))
+	for _, terminal := range terminals {
+		elements = append(elements, *terminal)
+	}
+	return elements
+}
+
+// selectDefaultTerminal elige el terminal por defecto de la plantilla del breaker:
+// el de EquipEnd "1", o el primero definido, o T1 si no hay terminales
+func selectDefaultTerminal(template *Breaker) string {
+	if template == nil || len(template.Terminals) == 0 {
+		return defaultTerminalName
+	}
+
+	for _, terminal := range template.Terminals {
+		if terminal != nil && terminal.EquipEnd == "1" && terminal.Name != "" {
+			return terminal.Name
+		}
+	}
+
+	for _, terminal := range template.Terminals {
+		if terminal != nil && terminal.Name != "" {
+			return terminal.Name
+		}
+	}
+
+	return defaultTerminalName
+}
+
+// hasTerminal indica si la plantilla del breaker define el terminal indicado
+func hasTerminal(template *Breaker, name string) bool {
+	if template == nil || len(template.Terminals) == 0 {
+		return name == defaultTerminalName
+	}
+
+	for _, terminal := range template.Terminals {
+		if terminal != nil && terminal.Name == name {
+			return true
+		}
+	}
+	return false
+}
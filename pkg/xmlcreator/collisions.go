@@ -0,0 +1,142 @@
+// pkg/xmlcreator/collisions.go
+package xmlcreator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tipos de colisión entre puntos IFS
+const (
+	CollisionName    = "duplicate_name"
+	CollisionMonitor = "duplicate_monitor_address"
+	CollisionControl = "duplicate_control_address"
+)
+
+// Collision es un par de puntos IFS del mismo parent path que comparten
+// nombre o dirección
+type Collision struct {
+	ParentPath string
+	Kind       string
+	Value      string
+	FirstRow   int
+	SecondRow  int
+}
+
+// column retorna las columnas de entrada que originan el valor en conflicto
+func (c Collision) column() string {
+	switch c.Kind {
+	case CollisionMonitor:
+		return "MHB/MMB/MLB"
+	case CollisionControl:
+		return "CHB/CMB/CLB"
+	default:
+		return "Name"
+	}
+}
+
+// violation convierte la colisión en una violación para el reporte de validación
+func (c Collision) violation() Violation {
+	return Violation{
+		Row:     c.SecondRow,
+		Column:  c.column(),
+		Value:   c.Value,
+		Rule:    c.Kind,
+		Message: fmt.Sprintf("coincide con la fila %d en %s", c.FirstRow, c.ParentPath),
+	}
+}
+
+// findIFSCollisions revisa todos los IfsPoint generados, agrupados por IFS
+// parent path, y retorna cada par en conflicto
+func findIFSCollisions(groups []*stationGroup) []Collision {
+	detector := newCollisionDetector()
+	for _, group := range groups {
+		for _, ifs := range group.proc.result.IFSGroups {
+			for i, element := range ifs.Elements {
+				if point, ok := element.(*IfsPoint); ok {
+					detector.add(ifs.ParentPath, point, ifs.RowNums[i])
+				}
+			}
+		}
+	}
+	return detector.sorted()
+}
+
+// collisionDetector registra los IfsPoint por IFS parent path. Cada
+// repetición se empareja con la primera fila que usó el valor. Las
+// direcciones vacías o en cero indican que el punto no tiene monitoreo o
+// control y no se comparan
+type collisionDetector struct {
+	seen       map[string]map[string]int // parent path -> clave -> primera fila
+	collisions []Collision
+}
+
+func newCollisionDetector() *collisionDetector {
+	return &collisionDetector{seen: make(map[string]map[string]int)}
+}
+
+// add registra un punto IFS y las colisiones con los puntos anteriores
+func (d *collisionDetector) add(parentPath string, point *IfsPoint, rowNum int) {
+	firstRows, ok := d.seen[parentPath]
+	if !ok {
+		firstRows = make(map[string]int)
+		d.seen[parentPath] = firstRows
+	}
+
+	check := func(kind, value string) {
+		key := kind + "|" + value
+		if first, exists := firstRows[key]; exists {
+			d.collisions = append(d.collisions, Collision{
+				ParentPath: parentPath,
+				Kind:       kind,
+				Value:      value,
+				FirstRow:   first,
+				SecondRow:  rowNum,
+			})
+			return
+		}
+		firstRows[key] = rowNum
+	}
+
+	check(CollisionName, point.Name)
+	if address, used := ifsAddress(point.MonAddrHigh, point.MonAddrMiddle, point.MonAddrLow); used {
+		check(CollisionMonitor, address)
+	}
+	if address, used := ifsAddress(point.ConAddrHigh, point.ConAddrMiddle, point.ConAddrLow); used {
+		check(CollisionControl, address)
+	}
+}
+
+// sorted retorna las colisiones en el orden del archivo. Las estaciones se
+// procesan por grupo, así que el orden de detección puede ser otro
+func (d *collisionDetector) sorted() []Collision {
+	sort.SliceStable(d.collisions, func(i, j int) bool {
+		return d.collisions[i].SecondRow < d.collisions[j].SecondRow
+	})
+	return d.collisions
+}
+
+// ifsAddress une los bytes de una dirección IFS e indica si está en uso.
+// Cada byte se normaliza, para que 01 y 1 sean la misma dirección
+func ifsAddress(high, middle, low string) (string, bool) {
+	parts := []string{normalizeAddressByte(high), normalizeAddressByte(middle), normalizeAddressByte(low)}
+	used := false
+	for _, b := range parts {
+		if b != "" && b != "0" {
+			used = true
+		}
+	}
+	return strings.Join(parts, "."), used
+}
+
+// normalizeAddressByte quita los ceros a la izquierda y los espacios de un
+// byte de dirección. Un valor que no es entero se conserva para reportarlo
+func normalizeAddressByte(value string) string {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return value
+	}
+	return strconv.Itoa(n)
+}
@@ -2,118 +2,336 @@
 package xmlcreator
 
 import (
+	"errors"
 	"fmt"
 	"goScadaSur/pkg/config"
 	"goScadaSur/pkg/fileio"
 	"log"
+	"path/filepath"
 	"strings"
 )
 
-// CreateXMLFromFile procesa un archivo (CSV o Excel) y genera archivos XML
-func CreateXMLFromFile(inputFilePath string) error {
-	// Leer datos del archivo
-	log.Printf("[INFO] Leyendo datos desde: %s", inputFilePath)
-	_, dataRows, headerMap, err := fileio.ReadData(inputFilePath)
+// Options controla la lectura y la generación de XML
+type Options struct {
+	Read     fileio.ReadOptions
+	Force    bool      // Generar aunque haya errores de validación
+	Baseline *Baseline // Generar solo los cambios respecto de una entrega anterior
+	Schema   *Schema   // Esquema de los XDF generados (por defecto xml.schema)
+}
+
+// loadSchema carga el esquema de xml.schema si no se indicó uno
+func (o *Options) loadSchema() error {
+	if o.Schema != nil {
+		return nil
+	}
+	schema, err := LoadSchema(config.Global.XML.Schema)
 	if err != nil {
-		return fmt.Errorf("error leyendo archivo: %w", err)
+		return err
 	}
+	o.Schema = schema
+	return nil
+}
 
-	if len(dataRows) == 0 {
-		log.Println("[WARN] El archivo no contiene datos para procesar")
-		return nil
+// CreateXMLFromFile procesa un archivo (CSV o Excel) y genera archivos XML
+func CreateXMLFromFile(inputFilePath string, opts Options) error {
+	if err := opts.loadSchema(); err != nil {
+		return err
+	}
+	if _, err := createXML(inputFilePath, opts, make(map[string]bool)); err != nil {
+		return err
 	}
+	if opts.Baseline != nil {
+		_, err := opts.Baseline.saveRemoved(opts)
+		return err
+	}
+	return nil
+}
+
+// createXML procesa un dataset (archivo u hoja) y retorna el resumen de sus
+// estaciones. usedNames se comparte entre datasets para no sobrescribir archivos
+func createXML(inputFilePath string, opts Options, usedNames map[string]bool) ([]StationSummary, error) {
+	readOpts := opts.Read
+
+	// Leer datos del archivo
+	if readOpts.Sheet != "" {
+		log.Printf("[INFO] Leyendo datos desde: %s (hoja %s)", inputFilePath, readOpts.Sheet)
+	} else {
+		log.Printf("[INFO] Leyendo datos desde: %s", inputFilePath)
+	}
+	if readOpts.BufferSize == 0 {
+		readOpts.BufferSize = config.Global.Processing.BufferSize
+	}
+	if readOpts.HeaderColumns == nil {
+		readOpts.HeaderColumns = config.Global.Validation.RequiredColumns
+		readOpts.HeaderSearchRows = config.Global.Files.HeaderSearchRows
+	}
+	reader, _, headerMap, err := fileio.OpenData(inputFilePath, readOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo: %w", err)
+	}
+	defer reader.Close()
 
 	// Validar columnas requeridas
 	if err := fileio.ValidateHeaders(headerMap, config.Global.Validation.RequiredColumns); err != nil {
-		return fmt.Errorf("validación de columnas fallida: %w", err)
+		return nil, fmt.Errorf("validación de columnas fallida: %w", err)
+	}
+
+	// Compilar reglas de nombres y de validación
+	naming, err := NewNamingRules(config.Global.Naming)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := NewValidationRules(config.Global.Validation)
+	if err != nil {
+		return nil, err
+	}
+	validator := &validatingReader{DataReader: reader, rules: rules, headerMap: headerMap}
+
+	// Procesar las filas a medida que se leen, agrupadas por estación
+	groups, total, err := groupRowsByStation(validator, headerMap, naming)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo: %w", err)
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("error leyendo archivo: %w: el archivo debe tener al menos una cabecera y una fila de datos", fileio.ErrNoData)
+	}
+
+	log.Printf("[OK] Datos leídos correctamente: %d filas", total)
+
+	// Nombres y direcciones repetidos en un mismo IFS se reportan junto con
+	// las violaciones de validation.rules
+	for _, c := range findIFSCollisions(groups) {
+		log.Printf("[ERROR] %s '%s' en %s: filas %d y %d", c.Kind, c.Value, c.ParentPath, c.FirstRow, c.SecondRow)
+		validator.violations = append(validator.violations, c.violation())
+	}
+
+	// Ningún XML se escribe si hay errores de validación, salvo con --force
+	if n := len(validator.violations); n > 0 {
+		reportPath := validationReportPath(inputFilePath, readOpts.Sheet)
+		if err := writeValidationReport(reportPath, config.Global.Validation.ReportFormat, validator.violations); err != nil {
+			return nil, err
+		}
+		log.Printf("[ERROR] %d errores de validación (reporte: %s)", n, reportPath)
+
+		if !opts.Force {
+			return nil, fmt.Errorf("%w: %d errores (ver %s o use --force)", ErrValidation, n, reportPath)
+		}
+		log.Printf("[WARN] --force: se generan los XML a pesar de los errores de validación")
+	}
+	log.Printf("[INFO] Estaciones detectadas: %d", len(groups))
+
+	summaries := make([]StationSummary, 0, len(groups))
+
+	for _, group := range groups {
+		summary, err := processStation(group, usedNames, opts)
+		if err != nil {
+			return summaries, fmt.Errorf("error procesando estación '%s': %w", group.Key, err)
+		}
+		summaries = append(summaries, summary)
 	}
 
-	log.Printf("[OK] Datos leídos correctamente: %d filas", len(dataRows))
+	printSummary(summaries)
+	return summaries, nil
+}
+
+// validationReportPath retorna la ruta del reporte de validación de un dataset
+func validationReportPath(inputFilePath, sheet string) string {
+	base := strings.TrimSuffix(filepath.Base(inputFilePath), filepath.Ext(inputFilePath))
+	if sheet != "" {
+		base += "_" + sheet
+	}
+	return config.GetOutputPath(fmt.Sprintf("%s_validacion.%s", base, config.Global.Validation.ReportFormat))
+}
+
+// processStation procesa las filas de una estación y genera sus archivos XML
+func processStation(group *stationGroup, usedNames map[string]bool, opts Options) (StationSummary, error) {
+	log.Printf("[INFO] Procesando estación %s (%d filas)", group.Key, group.Rows)
 
-	// Procesar las filas
-	result, err := processRows(dataRows, headerMap)
+	summary := StationSummary{
+		Station: group.Key,
+		Rows:    group.Rows,
+	}
+
+	// Completar el procesamiento de las filas
+	result, err := group.proc.finish()
 	if err != nil {
-		return fmt.Errorf("error procesando filas: %w", err)
+		return summary, fmt.Errorf("error procesando filas: %w", err)
+	}
+
+	for _, immGroup := range result.IMMGroups {
+		summary.IMMElements += len(immGroup.Elements)
+	}
+	for _, breakerGroup := range result.BreakerGroups {
+		summary.BreakerLinks += countBreakerLinks(breakerGroup.Elements)
+	}
+	for _, ifsGroup := range result.IFSGroups {
+		summary.IFSPoints += len(ifsGroup.Elements)
 	}
 
 	// Generar archivos XML
-	if err := generateXMLFiles(result, dataRows[0], headerMap); err != nil {
-		return fmt.Errorf("error generando archivos XML: %w", err)
+	files, err := generateXMLFiles(result, group.Key, stationFileBase(group.Key, usedNames), opts)
+	if err != nil {
+		return summary, fmt.Errorf("error generando archivos XML: %w", err)
 	}
+	summary.Files = files
 
-	return nil
+	return summary, nil
+}
+
+// IFSGroup agrupa los puntos IFS que comparten el mismo parent (DASIP)
+type IFSGroup struct {
+	DasIP      string
+	ParentPath string
+	Elements   []any
+	RowNums    []int // Fila de origen de cada elemento
+}
+
+// ElementGroup agrupa elementos IMM bajo un mismo Parent
+type ElementGroup struct {
+	ParentPath string
+	Elements   []any
 }
 
 // ProcessingResult contiene los resultados del procesamiento de filas
 type ProcessingResult struct {
-	ElementsIMM  []any
-	ElementsIFS  []any
-	BreakerName  string
-	BreakerLinks []any
+	IMMGroups     []*ElementGroup
+	IFSGroups     []*IFSGroup
+	BreakerGroups []*ElementGroup
 }
 
-// processRows procesa todas las filas del archivo de datos
-func processRows(dataRows [][]string, headerMap map[string]int) (*ProcessingResult, error) {
-	result := &ProcessingResult{
-		ElementsIMM:  make([]any, 0),
-		ElementsIFS:  make([]any, 0),
-		BreakerLinks: make([]any, 0),
+// addIMMElement agrega un elemento IMM al grupo de su parent
+func (r *ProcessingResult) addIMMElement(parentPath string, element any) {
+	for _, group := range r.IMMGroups {
+		if group.ParentPath == parentPath {
+			group.Elements = append(group.Elements, element)
+			return
+		}
 	}
 
-	var cbRowData []string
+	r.IMMGroups = append(r.IMMGroups, &ElementGroup{
+		ParentPath: parentPath,
+		Elements:   []any{element},
+	})
+}
 
-	// Procesar cada fila
-	for rowIdx, row := range dataRows {
-		// Validación básica de la fila
-		if len(row) == 0 {
-			continue
+// addIFSPoint agrega un punto IFS al grupo correspondiente a su DASIP
+func (r *ProcessingResult) addIFSPoint(dasIP string, point *IfsPoint, rowNum int) {
+	parentPath := config.GetIfsParentPath(dasIP)
+	for _, group := range r.IFSGroups {
+		if group.ParentPath == parentPath {
+			group.Elements = append(group.Elements, point)
+			group.RowNums = append(group.RowNums, rowNum)
+			return
 		}
+	}
 
-		elementKey := fileio.GetCellValue(row, headerMap["ELEMENT"])
-		if elementKey == "" {
-			log.Printf("[WARN] Fila %d: ELEMENT vacío, saltando...", rowIdx+2)
-			continue
-		}
+	r.IFSGroups = append(r.IFSGroups, &IFSGroup{
+		DasIP:      dasIP,
+		ParentPath: parentPath,
+		Elements:   []any{point},
+		RowNums:    []int{rowNum},
+	})
+}
 
-		// Obtener plantilla
-		template, isTemplateFound := GetTemplate(elementKey)
-		isBreakerType := (isTemplateFound && template.Breaker != nil) || elementKey == "CB"
+// rowProcessor procesa de forma incremental las filas de una estación
+type rowProcessor struct {
+	headerMap       map[string]int
+	naming          *NamingRules
+	result          *ProcessingResult
+	bays            *bayCollector
+	placeholderErrs []error
+}
 
-		// Guardar datos del CB para enlaces posteriores
-		if elementKey == "CB" {
-			cbRowData = row
-		}
+// newRowProcessor crea un procesador de filas vacío
+func newRowProcessor(headerMap map[string]int, naming *NamingRules) *rowProcessor {
+	return &rowProcessor{
+		headerMap: headerMap,
+		naming:    naming,
+		result:    &ProcessingResult{},
+		bays:      newBayCollector(naming),
+	}
+}
 
-		// Generar nombre de visualización
-		displayName := generateDisplayName(elementKey, row, headerMap)
+// process procesa una fila. La fila no se conserva: solo los elementos generados
+func (p *rowProcessor) process(rowNum int, row []string) error {
+	headerMap := p.headerMap
 
-		// Procesar elemento IFS
-		ifsPoint := createIfsPoint(row, headerMap, displayName, isBreakerType)
-		result.ElementsIFS = append(result.ElementsIFS, ifsPoint)
+	elementKey := fileio.GetCellValue(row, headerMap["ELEMENT"])
+	if elementKey == "" {
+		log.Printf("[WARN] Fila %d: ELEMENT vacío, saltando...", rowNum)
+		return nil
+	}
 
-		// Procesar elemento IMM
-		if !isTemplateFound {
-			log.Printf("[WARN] Plantilla '%s' no encontrada", elementKey)
-			continue
-		}
+	// Obtener plantilla
+	template, isTemplateFound := GetTemplate(elementKey)
+	isBreakerType := isBreakerElement(elementKey)
 
-		element, err := createIMMElement(template, displayName, row, headerMap)
-		if err != nil {
-			log.Printf("[WARN] Error procesando elemento '%s': %v", elementKey, err)
-			continue
-		}
+	// Nombre de visualización y path IMM de la fila
+	data, err := rowNamingData(elementKey, row, headerMap, p.naming)
+	if err != nil {
+		return fmt.Errorf("fila %d: %w", rowNum, err)
+	}
+	displayName, immPath := data["DisplayName"], data["ImmPath"]
+
+	// Registrar breakers y mediciones de la bahía para enlaces posteriores
+	if err := p.bays.collect(elementKey, rowNum, data); err != nil {
+		return fmt.Errorf("fila %d: %w", rowNum, err)
+	}
 
-		if element != nil {
-			result.ElementsIMM = append(result.ElementsIMM, element)
+	// Procesar elemento IFS
+	ifsPoint, err := createIfsPoint(row, headerMap, data, p.naming, isBreakerType)
+	if err != nil {
+		return fmt.Errorf("fila %d: %w", rowNum, err)
+	}
+	dasIP := fileio.GetCellValueOrDefault(row, headerMap, "DASIP", "")
+	p.result.addIFSPoint(dasIP, ifsPoint, rowNum)
+
+	// Procesar elemento IMM
+	if !isTemplateFound {
+		log.Printf("[WARN] Fila %d: plantilla '%s' no encontrada", rowNum, elementKey)
+		return nil
+	}
+
+	ctx := NewPlaceholderContext(rowNum, row, headerMap, displayName)
+	element, err := createIMMElement(template, ctx)
+	if err != nil {
+		var placeholderErr *PlaceholderError
+		if errors.As(err, &placeholderErr) {
+			p.placeholderErrs = append(p.placeholderErrs, err)
+			return nil
 		}
+		log.Printf("[WARN] Fila %d: error procesando elemento '%s': %v", rowNum, elementKey, err)
+		return nil
 	}
 
-	// Procesar enlaces de breaker
-	if cbRowData != nil {
-		result.BreakerLinks, result.BreakerName = createBreakerLinks(cbRowData, dataRows, headerMap)
+	if element != nil {
+		p.result.addIMMElement(immPath, element)
 	}
+	return nil
+}
+
+// finish cierra el procesamiento y construye los enlaces de breaker por bahía
+func (p *rowProcessor) finish() (*ProcessingResult, error) {
+	// Los placeholders desconocidos son errores de plantilla, no de datos
+	if len(p.placeholderErrs) > 0 {
+		return nil, fmt.Errorf("placeholders sin resolver en plantillas:\n%w", errors.Join(p.placeholderErrs...))
+	}
+
+	p.result.BreakerGroups = p.bays.buildLinks()
+	return p.result, nil
+}
 
-	return result, nil
+// countBreakerLinks cuenta los enlaces de medición de todos los terminales
+func countBreakerLinks(links []any) int {
+	total := 0
+	for _, link := range links {
+		if terminal, ok := link.(LinkedTerminal); ok {
+			total += len(terminal.Links)
+		}
+	}
+	return total
 }
 
 // generateDisplayName genera el nombre de visualización para un elemento
@@ -125,222 +343,227 @@ func generateDisplayName(elementKey string, row []string, headerMap map[string]i
 	return elementKey
 }
 
-// createIfsPoint crea un punto IFS basado en los datos de la fila
-func createIfsPoint(row []string, headerMap map[string]int, displayName string, isBreakerType bool) *IfsPoint {
+// isBreakerElement indica si el ELEMENT genera un breaker (plantilla con
+// Breaker o CB)
+func isBreakerElement(elementKey string) bool {
+	template, found := GetTemplate(elementKey)
+	return (found && template.Breaker != nil) || elementKey == "CB"
+}
+
+// rowNamingData retorna los datos de nombre de una fila con su nombre de
+// visualización y su path IMM
+func rowNamingData(elementKey string, row []string, headerMap map[string]int, naming *NamingRules) (NamingData, error) {
+	data := newNamingData(row, headerMap)
+	data["DisplayName"] = generateDisplayName(elementKey, row, headerMap)
+	immPath, err := naming.ImmParentPath(data)
+	if err != nil {
+		return nil, err
+	}
+	data["ImmPath"] = immPath
+	return data, nil
+}
+
+// createIfsPoint crea un punto IFS basado en los datos de la fila. El nombre
+// y el PathB se generan con las reglas de la sección naming
+func createIfsPoint(row []string, headerMap map[string]int, data NamingData, naming *NamingRules, isBreakerType bool) (*IfsPoint, error) {
+	displayName := data["DisplayName"]
+
 	// Determinar partes del nombre IFS
-	var ifsNamePart, ifsPathPart string
 	if isBreakerType {
-		ifsNamePart = fmt.Sprintf("%s_%s", displayName, displayName)
-		ifsPathPart = fmt.Sprintf("%s/%s", displayName, displayName)
+		data["NamePart"] = fmt.Sprintf("%s_%s", displayName, displayName)
+		data["PathPart"] = fmt.Sprintf("%s/%s", displayName, displayName)
 	} else {
-		ifsNamePart = displayName
-		ifsPathPart = displayName
+		data["NamePart"] = displayName
+		data["PathPart"] = displayName
 	}
 
-	// Determinar sufijo
-	suffix := "M"
-	if fileio.GetCellValue(row, headerMap["TYPE"]) == "SP_SC" {
-		suffix = "MC"
-	}
+	// Determinar sufijo y tipos según TYPE
+	signalType := config.GetSignalType(fileio.GetCellValue(row, headerMap["TYPE"]))
+	data["Suffix"] = signalType.Suffix
+	data["ConType"] = signalType.ConType
+	data["MonType"] = signalType.MonType
 
 	// Obtener SBO
 	sbo := fileio.GetCellValueOrDefault(row, headerMap, "SBO", "0")
 
-	// Determinar ConType
-	conType := "0"
-	if fileio.GetCellValue(row, headerMap["TYPE"]) == "SP_SC" {
-		conType = "45"
-	}
-
 	// Construir nombre del punto IFS
-	b1 := fileio.GetCellValue(row, headerMap["B1"])
-	b2 := fileio.GetCellValue(row, headerMap["B2"])
-	b3 := fileio.GetCellValue(row, headerMap["B3"])
-	info := fileio.GetCellValue(row, headerMap["INFO"])
-
-	ifsPointName := fmt.Sprintf("%s_%s_%s_%s_%s_%s", b1, b2, b3, ifsNamePart, info, suffix)
+	ifsPointName, err := naming.IfsName(data)
+	if err != nil {
+		return nil, err
+	}
 
 	// Construir PathB
-	empresa := fileio.GetCellValue(row, headerMap["EMPRESA"])
-	region := fileio.GetCellValue(row, headerMap["REGION"])
-	pathB := fmt.Sprintf("ELECTRICITY/NETWORK/%s/%s/%s/%s/%s/%s/%s",
-		empresa, region, b1, b2, b3, ifsPathPart, info)
+	pathB, err := naming.PathB(data)
+	if err != nil {
+		return nil, err
+	}
 
 	return &IfsPoint{
 		Name:          ifsPointName,
-		MonAddrHigh:   fileio.GetCellValueOrDefault(row, headerMap, "MHB", "0"),
-		MonAddrMiddle: fileio.GetCellValueOrDefault(row, headerMap, "MMB", "0"),
-		MonAddrLow:    fileio.GetCellValueOrDefault(row, headerMap, "MLB", "0"),
-		MonType:       "0",
-		ConAddrHigh:   fileio.GetCellValueOrDefault(row, headerMap, "CHB", "0"),
-		ConAddrMiddle: fileio.GetCellValueOrDefault(row, headerMap, "CMB", "0"),
-		ConAddrLow:    fileio.GetCellValueOrDefault(row, headerMap, "CLB", "0"),
-		ConType:       conType,
+		MonAddrHigh:   normalizeAddressByte(fileio.GetCellValueOrDefault(row, headerMap, "MHB", "0")),
+		MonAddrMiddle: normalizeAddressByte(fileio.GetCellValueOrDefault(row, headerMap, "MMB", "0")),
+		MonAddrLow:    normalizeAddressByte(fileio.GetCellValueOrDefault(row, headerMap, "MLB", "0")),
+		MonType:       signalType.MonType,
+		ConAddrHigh:   normalizeAddressByte(fileio.GetCellValueOrDefault(row, headerMap, "CHB", "0")),
+		ConAddrMiddle: normalizeAddressByte(fileio.GetCellValueOrDefault(row, headerMap, "CMB", "0")),
+		ConAddrLow:    normalizeAddressByte(fileio.GetCellValueOrDefault(row, headerMap, "CLB", "0")),
+		ConType:       signalType.ConType,
 		SelectBefore:  sbo,
 		Link_IfsPointLinksToInfo: &Link_IfsPointLinksToInfo{
 			PathB: pathB,
 		},
-	}
+	}, nil
 }
 
-// createIMMElement crea un elemento IMM basado en una plantilla
-func createIMMElement(template ElementDef, displayName string, row []string, headerMap map[string]int) (any, error) {
+// createIMMElement crea un elemento IMM basado en una plantilla, sustituyendo
+// los placeholders de todos sus atributos con los valores de la fila
+func createIMMElement(template ElementDef, ctx *PlaceholderContext) (any, error) {
 	// Hacer copia profunda de la plantilla
 	instance, err := DeepCopyElement(template)
 	if err != nil {
 		return nil, err
 	}
 
-	aor := fileio.GetCellValue(row, headerMap["AOR"])
+	// Los nombres sin placeholder toman el nombre de visualización y el AOR
+	// vacío toma el de la fila
+	if instance.Analog != nil {
+		applyNameDefaults(&instance.Analog.Name, &instance.Analog.AreaOfResponsibilityId)
+	}
+	if instance.Discrete != nil {
+		applyNameDefaults(&instance.Discrete.Name, &instance.Discrete.AreaOfResponsibilityId)
+	}
+	if instance.Breaker != nil {
+		applyNameDefaults(&instance.Breaker.Name, &instance.Breaker.AreaOfResponsibilityId)
+		if instance.Breaker.Discrete != nil {
+			applyNameDefaults(&instance.Breaker.Discrete.Name, &instance.Breaker.Discrete.AreaOfResponsibilityId)
+		}
+	}
+
+	if err := SubstitutePlaceholders(&instance, ctx); err != nil {
+		return nil, err
+	}
 
 	// Configurar según el tipo de elemento
 	if instance.Analog != nil {
-		instance.Analog.Name = displayName
-		instance.Analog.AreaOfResponsibilityId = aor
 		return instance.Analog, nil
 	}
 
 	if instance.Discrete != nil {
-		instance.Discrete.Name = displayName
-		instance.Discrete.AreaOfResponsibilityId = aor
 		return instance.Discrete, nil
 	}
 
 	if instance.Breaker != nil {
-		instance.Breaker.Name = displayName
-		instance.Breaker.AreaOfResponsibilityId = aor
-		if instance.Breaker.Discrete != nil {
-			instance.Breaker.Discrete.Name = displayName
-			instance.Breaker.Discrete.AreaOfResponsibilityId = aor
-		}
 		return instance.Breaker, nil
 	}
 
 	return nil, nil
 }
 
-// createBreakerLinks crea los enlaces del breaker
-func createBreakerLinks(cbRow []string, allRows [][]string, headerMap map[string]int) ([]any, string) {
-	targetMeasurements := map[string]bool{
-		"P":    true,
-		"Q":    true,
-		"I_S":  true,
-		"U_RS": true,
+// applyNameDefaults prepara Name y AreaOfResponsibilityId antes de la sustitución
+func applyNameDefaults(name, aor *string) {
+	if !strings.Contains(*name, "{") {
+		*name = "{DISPLAY_NAME}"
 	}
-
-	var links []Link_TerminalMeasuredByMeasurement
-
-	empresa := fileio.GetCellValue(cbRow, headerMap["EMPRESA"])
-	region := fileio.GetCellValue(cbRow, headerMap["REGION"])
-	b1 := fileio.GetCellValue(cbRow, headerMap["B1"])
-	b2 := fileio.GetCellValue(cbRow, headerMap["B2"])
-	b3 := fileio.GetCellValue(cbRow, headerMap["B3"])
-
-	basePathB := fmt.Sprintf("ELECTRICITY/NETWORK/%s/%s/%s/%s/%s", empresa, region, b1, b2, b3)
-
-	// Buscar mediciones objetivo
-	for _, row := range allRows {
-		if len(row) <= headerMap["ELEMENT"] {
-			continue
-		}
-
-		elementKey := fileio.GetCellValue(row, headerMap["ELEMENT"])
-		if targetMeasurements[elementKey] {
-			displayName := strings.ReplaceAll(elementKey, "_", " ")
-			link := Link_TerminalMeasuredByMeasurement{
-				PathB: fmt.Sprintf("%s/%s", basePathB, displayName),
-			}
-			links = append(links, link)
-		}
+	if *aor == "" {
+		*aor = "{AOR}"
 	}
-
-	if len(links) == 0 {
-		return nil, ""
-	}
-
-	linkedTerminal := LinkedTerminal{
-		Name:  "T1",
-		Links: links,
-	}
-
-	return []any{linkedTerminal}, "CB"
 }
 
-// generateXMLFiles genera los archivos XML IFS e IMM
-func generateXMLFiles(result *ProcessingResult, firstRow []string, headerMap map[string]int) error {
-	// Obtener valores básicos
-	b3 := fileio.GetCellValue(firstRow, headerMap["B3"])
-	empresa := fileio.GetCellValue(firstRow, headerMap["EMPRESA"])
-	region := fileio.GetCellValue(firstRow, headerMap["REGION"])
-	b1 := fileio.GetCellValue(firstRow, headerMap["B1"])
-	b2 := fileio.GetCellValue(firstRow, headerMap["B2"])
-
-	// Obtener DASIP y mapear a IFS path
-	dasIP := fileio.GetCellValueOrDefault(firstRow, headerMap, "DASIP", "")
-	ifsParentPath := config.GetIfsParentPath(dasIP)
-	log.Printf("[INFO] DASIP '%s' -> %s", dasIP, ifsParentPath)
+// generateXMLFiles genera los archivos XML IFS e IMM de una estación y
+// retorna los nombres de los archivos escritos
+func generateXMLFiles(result *ProcessingResult, station StationKey, fileBase string, opts Options) ([]string, error) {
+	var files []string
 
 	// Generar archivo IFS
-	if err := generateIFSFile(b3, ifsParentPath, result.ElementsIFS); err != nil {
-		return fmt.Errorf("error generando archivo IFS: %w", err)
+	ifsFiles, err := generateIFSFile(fileBase, result.IFSGroups, opts)
+	files = append(files, ifsFiles...)
+	if err != nil {
+		return files, fmt.Errorf("error generando archivo IFS: %w", err)
 	}
 
 	// Generar archivo IMM
-	if err := generateIMMFile(b3, empresa, region, b1, b2, result); err != nil {
-		return fmt.Errorf("error generando archivo IMM: %w", err)
+	immFiles, err := generateIMMFile(fileBase, station, result, opts)
+	files = append(files, immFiles...)
+	if err != nil {
+		return files, fmt.Errorf("error generando archivo IMM: %w", err)
 	}
 
-	return nil
+	return files, nil
 }
 
-// generateIFSFile genera el archivo XML IFS
-func generateIFSFile(b3, parentPath string, elements []any) error {
-	if len(elements) == 0 {
-		log.Printf("[INFO] No se generará archivo IFS (sin elementos)")
-		return nil
+// generateIFSFile genera el archivo XML IFS con un Parent por cada DASIP
+func generateIFSFile(fileBase string, groups []*IFSGroup, opts Options) ([]string, error) {
+	var parents []Parent
+	for _, group := range groups {
+		log.Printf("[INFO] DASIP '%s' -> %s", group.DasIP, group.ParentPath)
+		parents = append(parents, Parent{
+			Path:     group.ParentPath,
+			Elements: group.Elements,
+		})
 	}
 
-	parents := []Parent{{
-		Path:     parentPath,
-		Elements: elements,
-	}}
-
-	fileName := fmt.Sprintf("%s%s", b3, config.Global.Output.Suffixes["ifs"])
-	return createAndSaveXML(fileName, parents)
+	return saveXDF(fileBase, "ifs", parents, opts)
 }
 
 // generateIMMFile genera el archivo XML IMM
-func generateIMMFile(b3, empresa, region, b1, b2 string, result *ProcessingResult) error {
-	if len(result.ElementsIMM) == 0 {
-		log.Printf("[INFO] No se generará archivo IMM (sin elementos)")
-		return nil
+func generateIMMFile(fileBase string, station StationKey, result *ProcessingResult, opts Options) ([]string, error) {
+	// Con línea base se compara igual, para registrar los elementos eliminados
+	if len(result.IMMGroups) == 0 && opts.Baseline == nil {
+		log.Printf("[INFO] No se generará archivo IMM para %s (sin elementos)", station)
+		return nil, nil
 	}
 
-	immParentPath := fmt.Sprintf("ELECTRICITY/NETWORK/%s/%s/%s/%s/%s", empresa, region, b1, b2, b3)
-
-	parents := []Parent{{
-		Path:     immParentPath,
-		Elements: result.ElementsIMM,
-	}}
+	var parents []Parent
+	for _, group := range result.IMMGroups {
+		parents = append(parents, Parent{
+			Path:     group.ParentPath,
+			Elements: group.Elements,
+		})
+	}
 
 	// Agregar enlaces de breaker si existen
-	if len(result.BreakerLinks) > 0 && result.BreakerName != "" {
+	for _, group := range result.BreakerGroups {
 		parents = append(parents, Parent{
-			Path:     fmt.Sprintf("%s/%s", immParentPath, result.BreakerName),
-			Elements: result.BreakerLinks,
+			Path:     group.ParentPath,
+			Elements: group.Elements,
 		})
 	}
 
-	fileName := fmt.Sprintf("%s%s", b3, config.Global.Output.Suffixes["imm"])
-	return createAndSaveXML(fileName, parents)
+	return saveXDF(fileBase, "imm", parents, opts)
 }
 
-// createAndSaveXML crea y guarda un archivo XML
-func createAndSaveXML(fileName string, parents []Parent) error {
+// saveXDF escribe el archivo de un tipo (ifs o imm) con el sufijo de
+// output.suffixes. Con línea base escribe el delta y el archivo de borrado
+func saveXDF(fileBase, kind string, parents []Parent, opts Options) ([]string, error) {
+	fileName := fileBase + config.Global.Output.Suffixes[kind]
+	if opts.Baseline != nil {
+		if baselinePath, found := opts.Baseline.lookup(fileName); found {
+			return saveDelta(fileName, fileBase+config.Global.Output.Suffixes[kind+"_delete"], parents, baselinePath, opts)
+		}
+		log.Printf("[INFO] %s no está en la línea base; se genera completo", fileName)
+	}
+
+	written, err := createAndSaveXML(fileName, parents, opts)
+	if err != nil || written == "" {
+		return nil, err
+	}
+	return []string{written}, nil
+}
+
+// createAndSaveXML crea, valida contra el esquema y guarda un archivo XML.
+// Retorna el nombre del archivo escrito, o una cadena vacía si no había contenido
+func createAndSaveXML(fileName string, parents []Parent, opts Options) (string, error) {
 	// Validar que haya contenido
-	if len(parents) == 0 || (len(parents[0].Elements) == 0 && (len(parents) == 1 || len(parents[1].Elements) == 0)) {
+	hasElements := false
+	for _, parent := range parents {
+		if len(parent.Elements) > 0 {
+			hasElements = true
+			break
+		}
+	}
+	if !hasElements {
 		log.Printf("[INFO] No se generará '%s' (sin elementos)", fileName)
-		return nil
+		return "", nil
 	}
 
 	// Construir estructura XDF
@@ -352,14 +575,18 @@ func createAndSaveXML(fileName string, parents []Parent) error {
 		},
 	}
 
+	if err := validateXDF(fileName, xdf, opts.Schema, opts.Force); err != nil {
+		return "", err
+	}
+
 	// Escribir XML
 	fullPath := config.GetOutputPath(fileName)
 	writer := fileio.NewXMLWriter(fullPath, config.Global.XML.Indent)
 
 	if err := writer.Write(xdf); err != nil {
-		return fmt.Errorf("error escribiendo XML '%s': %w", fileName, err)
+		return "", fmt.Errorf("error escribiendo XML '%s': %w", fileName, err)
 	}
 
 	log.Printf("[OK] Archivo generado: %s", fileName)
-	return nil
+	return fileName, nil
 }
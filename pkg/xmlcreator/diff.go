@@ -0,0 +1,325 @@
+// pkg/xmlcreator/diff.go
+package xmlcreator
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"goScadaSur/pkg/fileio"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tipos de cambio de un elemento
+const (
+	DiffAdded    = "added"
+	DiffRemoved  = "removed"
+	DiffModified = "modified"
+)
+
+// AttributeChange es un atributo distinto entre las dos versiones. Los
+// atributos de los hijos se nombran con su ruta, p. ej.
+// AnalogValue[MvMoment].Archive. Old o New es nil si el atributo no existe
+type AttributeChange struct {
+	Attribute string  `json:"attribute"`
+	Old       *string `json:"old"`
+	New       *string `json:"new"`
+}
+
+// ElementDiff es un elemento agregado, eliminado o modificado
+type ElementDiff struct {
+	Change     string            `json:"change"`
+	ParentPath string            `json:"parent_path"`
+	Type       string            `json:"type"`
+	Name       string            `json:"name"`
+	Attributes []AttributeChange `json:"attributes,omitempty"`
+}
+
+// XDFDiff es la comparación semántica de dos archivos XDF: los elementos se
+// identifican por Path del Parent, tipo y Name, sin importar el orden ni el
+// formato del archivo
+type XDFDiff struct {
+	Old      string        `json:"old"`
+	New      string        `json:"new"`
+	Added    int           `json:"added"`
+	Removed  int           `json:"removed"`
+	Modified int           `json:"modified"`
+	Elements []ElementDiff `json:"elements"`
+}
+
+// xmlNode es un elemento XML genérico: atributos e hijos, sin texto
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xmlNode  `xml:",any"`
+}
+
+// attr retorna el valor de un atributo, o "" si no existe
+func (n *xmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// rawXDF lee un XDF sin interpretar sus elementos, para compararlos todos
+type rawXDF struct {
+	Parents []struct {
+		Path     string    `xml:"Path,attr"`
+		Elements []xmlNode `xml:",any"`
+	} `xml:"Instances>Parent"`
+}
+
+// diffElement es un elemento de un XDF con sus atributos aplanados
+type diffElement struct {
+	key        string
+	parentPath string
+	typ        string
+	name       string
+	attrs      map[string]string
+	node       xmlNode
+}
+
+// DiffXDF compara dos archivos XDF
+func DiffXDF(oldPath, newPath string) (*XDFDiff, error) {
+	oldElements, err := loadDiffElements(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newElements, err := loadDiffElements(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &XDFDiff{Old: oldPath, New: newPath, Elements: []ElementDiff{}}
+	for key, old := range oldElements {
+		current, exists := newElements[key]
+		if !exists {
+			diff.Elements = append(diff.Elements, old.diff(DiffRemoved, nil))
+			diff.Removed++
+			continue
+		}
+		if changes := diffAttributes(old.attrs, current.attrs); len(changes) > 0 {
+			diff.Elements = append(diff.Elements, old.diff(DiffModified, changes))
+			diff.Modified++
+		}
+	}
+	for key, current := range newElements {
+		if _, exists := oldElements[key]; !exists {
+			diff.Elements = append(diff.Elements, current.diff(DiffAdded, nil))
+			diff.Added++
+		}
+	}
+
+	sort.Slice(diff.Elements, func(i, j int) bool {
+		a, b := diff.Elements[i], diff.Elements[j]
+		if a.ParentPath != b.ParentPath {
+			return a.ParentPath < b.ParentPath
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+	return diff, nil
+}
+
+// diff construye el ElementDiff del elemento
+func (e *diffElement) diff(change string, attributes []AttributeChange) ElementDiff {
+	return ElementDiff{
+		Change:     change,
+		ParentPath: e.parentPath,
+		Type:       e.typ,
+		Name:       e.name,
+		Attributes: attributes,
+	}
+}
+
+// loadDiffElements lee un XDF y retorna sus elementos por clave
+func loadDiffElements(filePath string) (map[string]*diffElement, error) {
+	var xdf rawXDF
+	if err := fileio.ReadXML(filePath, &xdf); err != nil {
+		return nil, err
+	}
+
+	elements := make(map[string]*diffElement)
+	for _, element := range indexDiffElements(xdf) {
+		elements[element.key] = element
+	}
+	return elements, nil
+}
+
+// indexDiffElements retorna los elementos del XDF en orden, con su clave Path
+// + tipo + Name. Un elemento repetido con la misma clave se numera (#2, #3...)
+func indexDiffElements(xdf rawXDF) []*diffElement {
+	var elements []*diffElement
+	keys := make(map[string]bool)
+	for _, parent := range xdf.Parents {
+		for _, node := range parent.Elements {
+			element := &diffElement{
+				parentPath: parent.Path,
+				typ:        node.XMLName.Local,
+				name:       node.attr("Name"),
+				attrs:      make(map[string]string),
+				node:       node,
+			}
+			flattenAttributes(node, "", element.attrs)
+
+			base := element.name
+			element.key = element.parentPath + "\x00" + element.typ + "\x00" + element.name
+			for n := 2; keys[element.key]; n++ {
+				element.name = base + "#" + strconv.Itoa(n)
+				element.key = element.parentPath + "\x00" + element.typ + "\x00" + element.name
+			}
+			keys[element.key] = true
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// flattenAttributes agrega los atributos del nodo y de sus hijos con su ruta.
+// Los hijos se identifican por Name o PathB para que el orden no importe
+func flattenAttributes(node xmlNode, prefix string, attrs map[string]string) {
+	for _, a := range node.Attrs {
+		attrs[prefix+a.Name.Local] = a.Value
+	}
+
+	seen := make(map[string]int)
+	for _, child := range node.Children {
+		id := child.attr("Name")
+		if id == "" {
+			id = child.attr("PathB")
+		}
+		key := fmt.Sprintf("%s[%s]", child.XMLName.Local, id)
+		if seen[key]++; seen[key] > 1 {
+			key = fmt.Sprintf("%s[%s#%d]", child.XMLName.Local, id, seen[key])
+		}
+		// Un hijo vacío también cuenta como presente
+		if len(child.Attrs) == 0 && len(child.Children) == 0 {
+			attrs[prefix+key] = ""
+		}
+		flattenAttributes(child, prefix+key+".", attrs)
+	}
+}
+
+// diffAttributes retorna los atributos agregados, eliminados o cambiados,
+// ordenados por nombre
+func diffAttributes(oldAttrs, newAttrs map[string]string) []AttributeChange {
+	var changes []AttributeChange
+	for name, oldValue := range oldAttrs {
+		newValue, exists := newAttrs[name]
+		if !exists {
+			changes = append(changes, AttributeChange{Attribute: name, Old: &oldValue})
+		} else if newValue != oldValue {
+			changes = append(changes, AttributeChange{Attribute: name, Old: &oldValue, New: &newValue})
+		}
+	}
+	for name, newValue := range newAttrs {
+		if _, exists := oldAttrs[name]; !exists {
+			changes = append(changes, AttributeChange{Attribute: name, New: &newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Attribute < changes[j].Attribute })
+	return changes
+}
+
+// diffSymbols son los prefijos de cada tipo de cambio en el reporte de texto
+var diffSymbols = map[string]string{
+	DiffAdded:    "+",
+	DiffRemoved:  "-",
+	DiffModified: "~",
+}
+
+// Print escribe el reporte en texto: una línea por elemento y, en los
+// modificados, una línea por atributo
+func (d *XDFDiff) Print(w io.Writer) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", d.Old, d.New)
+	for _, e := range d.Elements {
+		fmt.Fprintf(w, "%s %s %s/%s\n", diffSymbols[e.Change], e.Type, e.ParentPath, e.Name)
+		for _, a := range e.Attributes {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", a.Attribute, quoteValue(a.Old), quoteValue(a.New))
+		}
+	}
+	fmt.Fprintf(w, "%d agregados, %d eliminados, %d modificados\n", d.Added, d.Removed, d.Modified)
+}
+
+// quoteValue muestra un valor entre comillas, o (no existe) si falta
+func quoteValue(value *string) string {
+	if value == nil {
+		return "(no existe)"
+	}
+	return strconv.Quote(*value)
+}
+
+// WriteJSON escribe el reporte en JSON
+func (d *XDFDiff) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando reporte: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// diffHTML es la plantilla del reporte HTML
+var diffHTML = template.Must(template.New("xdf-diff").Funcs(template.FuncMap{
+	"value":  quoteValue,
+	"symbol": func(change string) string { return diffSymbols[change] },
+}).Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="UTF-8">
+<title>Diferencias XDF</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+td.attrs { font-family: monospace; white-space: pre; }
+tr.added { background: #e6ffed; }
+tr.removed { background: #ffeef0; }
+tr.modified { background: #fff8c5; }
+</style>
+</head>
+<body>
+<h1>Diferencias XDF</h1>
+<p>Anterior: <code>{{.Old}}</code><br>Nuevo: <code>{{.New}}</code></p>
+<p>{{.Added}} agregados, {{.Removed}} eliminados, {{.Modified}} modificados</p>
+{{if .Elements}}<table>
+<tr><th></th><th>Parent</th><th>Tipo</th><th>Name</th><th>Atributos</th></tr>
+{{range .Elements}}<tr class="{{.Change}}"><td>{{symbol .Change}}</td><td>{{.ParentPath}}</td><td>{{.Type}}</td><td>{{.Name}}</td><td class="attrs">{{range .Attributes}}{{.Attribute}}: {{value .Old}} &rarr; {{value .New}}
+{{end}}</td></tr>
+{{end}}</table>{{else}}<p>Sin diferencias.</p>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML escribe el reporte como página HTML
+func (d *XDFDiff) WriteHTML(w io.Writer) error {
+	if err := diffHTML.Execute(w, d); err != nil {
+		return fmt.Errorf("error generando reporte HTML: %w", err)
+	}
+	return nil
+}
+
+// WriteReport escribe el reporte en el formato indicado (text, json o html)
+func (d *XDFDiff) WriteReport(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "", "text":
+		d.Print(w)
+		return nil
+	case "json":
+		return d.WriteJSON(w)
+	case "html":
+		return d.WriteHTML(w)
+	default:
+		return fmt.Errorf("formato de reporte '%s' no soportado (use text, json o html)", format)
+	}
+}
@@ -0,0 +1,772 @@
+// pkg/xmlcreator/importer.go
+package xmlcreator
+
+import (
+	"fmt"
+	"goScadaSur/pkg/config"
+	"goScadaSur/pkg/fileio"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxPointCandidates limita las interpretaciones que se guardan por punto IFS
+const maxPointCandidates = 16
+
+// importColumns son las columnas de la planilla reconstruida, en el orden
+// habitual de las planillas de entrada
+var importColumns = []string{
+	"ELEMENT", "INFO", "TYPE", "EMPRESA", "REGION", "B1", "B2", "B3", "BAY", "AOR",
+	"DASIP", "SBO", "MHB", "MMB", "MLB", "CHB", "CMB", "CLB", "TERMINAL",
+}
+
+// derivedFields son los campos de NamingData que se calculan y no son columnas
+var derivedFields = map[string]bool{
+	"DisplayName": true,
+	"NamePart":    true,
+	"PathPart":    true,
+	"Suffix":      true,
+	"ConType":     true,
+	"MonType":     true,
+	"ImmPath":     true,
+	"Breaker":     true,
+}
+
+// ImportOptions controla la reconstrucción de la planilla desde los XML
+type ImportOptions struct {
+	AOR string // AOR de las filas sin elemento IMM del cual tomarlo
+}
+
+// XDFPair es un archivo IFS con su archivo IMM (opcional) de la misma estación
+type XDFPair struct {
+	Base string // Prefijo común, p. ej. el B3 de la estación
+	IFS  string
+	IMM  string
+}
+
+// SignalList es la planilla de señales reconstruida desde un par IFS/IMM
+type SignalList struct {
+	Columns []string
+	Rows    [][]string
+	Skipped int // Puntos IFS que no siguen las reglas de naming
+}
+
+// FindXDFPairs busca los archivos IFS de path (archivo o directorio) y les
+// asocia el archivo IMM con el mismo prefijo según output.suffixes
+func FindXDFPairs(path string) ([]XDFPair, error) {
+	ifsSuffix := config.Global.Output.Suffixes["ifs"]
+	immSuffix := config.Global.Output.Suffixes["imm"]
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo directorio '%s': %w", path, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ifsSuffix) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no hay archivos *%s en '%s'", ifsSuffix, path)
+		}
+	} else {
+		// Si se indica el IMM se usa el IFS de la misma estación
+		if strings.HasSuffix(path, immSuffix) {
+			path = strings.TrimSuffix(path, immSuffix) + ifsSuffix
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("los puntos se leen del archivo IFS: %w", err)
+			}
+		}
+		files = []string{path}
+	}
+
+	pairs := make([]XDFPair, 0, len(files))
+	for _, file := range files {
+		pair := XDFPair{IFS: file}
+		if strings.HasSuffix(file, ifsSuffix) {
+			prefix := strings.TrimSuffix(file, ifsSuffix)
+			pair.Base = filepath.Base(prefix)
+			if _, err := os.Stat(prefix + immSuffix); err == nil {
+				pair.IMM = prefix + immSuffix
+			}
+		} else {
+			pair.Base = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// ImportXDF reconstruye la planilla de señales que genera los XML indicados.
+// Cada punto IFS se convierte en una fila invirtiendo las reglas de naming;
+// el archivo IMM (opcional) aporta el AOR y el terminal de las mediciones
+// enlazadas al breaker, y fija el orden de las filas para que csv-xml vuelva
+// a agrupar los elementos igual
+func ImportXDF(pair XDFPair, opts ImportOptions) (*SignalList, error) {
+	naming, err := NewNamingRules(config.Global.Naming)
+	if err != nil {
+		return nil, err
+	}
+	im, err := newImporter(naming)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[INFO] Leyendo puntos IFS desde: %s", pair.IFS)
+	var ifs XDF
+	if err := fileio.ReadXML(pair.IFS, &ifs); err != nil {
+		return nil, err
+	}
+	if pair.IMM != "" {
+		log.Printf("[INFO] Leyendo elementos IMM desde: %s", pair.IMM)
+		var imm XDF
+		if err := fileio.ReadXML(pair.IMM, &imm); err != nil {
+			return nil, err
+		}
+		im.indexIMM(imm)
+	} else {
+		log.Printf("[WARN] %s: sin archivo IMM; el AOR se toma de --aor", pair.IFS)
+	}
+
+	list := &SignalList{}
+	var rows []*importedRow
+	for parentIdx, parent := range ifs.Instances.Parents {
+		dasIP := im.dasIP(parent.Path)
+		for _, element := range parent.Elements {
+			point, ok := element.(*IfsPoint)
+			if !ok {
+				continue
+			}
+			row, err := im.importPoint(point)
+			if err != nil {
+				log.Printf("[WARN] Punto IFS '%s': %v; se omite", point.Name, err)
+				list.Skipped++
+				continue
+			}
+			row.ifsParent = parentIdx
+			row.values["DASIP"] = dasIP
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: ningún punto IFS se pudo reconstruir", pair.IFS)
+	}
+
+	if unused := im.unusedIMM(); unused > 0 {
+		log.Printf("[WARN] %d elementos IMM no corresponden a ningún punto IFS", unused)
+	}
+	im.fillAOR(rows, opts.AOR)
+
+	list.Columns = importedColumns(rows)
+	for _, row := range importOrder(rows) {
+		values := make([]string, len(list.Columns))
+		for i, column := range list.Columns {
+			values[i] = row.values[column]
+		}
+		list.Rows = append(list.Rows, values)
+	}
+
+	log.Printf("[OK] %d filas reconstruidas desde %s", len(list.Rows), filepath.Base(pair.IFS))
+	return list, nil
+}
+
+// Write escribe la planilla en el formato indicado (csv o xlsx)
+func (l *SignalList) Write(format, filePath string) error {
+	writer, err := fileio.NewTableWriter(format, filePath)
+	if err != nil {
+		return err
+	}
+
+	header := make([]fileio.TableColumn, len(l.Columns))
+	for i, column := range l.Columns {
+		header[i] = fileio.TableColumn{Name: column}
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		writer.Close()
+		return err
+	}
+
+	cells := make([]fileio.Cell, len(l.Columns))
+	for _, row := range l.Rows {
+		for i, value := range row {
+			cells[i] = fileio.Cell{Value: value}
+		}
+		if err := writer.WriteRow(cells); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// importedRow es una fila reconstruida junto con su posición en los XML
+type importedRow struct {
+	values    map[string]string
+	ifsParent int
+	imm       *immEntry // Elemento IMM generado por la fila, si existe
+}
+
+// immEntry es un elemento IMM indexado por su Parent y nombre
+type immEntry struct {
+	parent  int
+	index   int
+	element any
+	used    bool
+}
+
+// bayTerminal es el terminal de breaker al que se enlaza una medición
+type bayTerminal struct {
+	name        string // Nombre del terminal
+	breakerPath string // Path del Parent que contiene los terminales
+}
+
+// pointCandidate es una interpretación del nombre y el PathB de un punto IFS
+type pointCandidate struct {
+	data       NamingData // Campos de la fila más los calculados
+	element    string     // ELEMENT
+	signalType string     // TYPE; vacío si corresponde al tipo por defecto
+}
+
+// importer invierte las reglas de naming para un par de archivos IFS/IMM
+type importer struct {
+	naming    *NamingRules
+	pathB     namePattern
+	ifsName   namePattern
+	imm       map[string][]*immEntry // ImmPath/nombre -> elementos en orden
+	terminals map[string]bayTerminal // PathB de la medición -> terminal
+	breakers  map[string]*Breaker    // Path del Parent de terminales -> plantilla del breaker
+	hasIMM    bool
+	dasips    map[string]string // Path IFS -> DASIP
+}
+
+// newImporter prepara los patrones inversos de path_b e ifs_name
+func newImporter(naming *NamingRules) (*importer, error) {
+	immPath, err := compilePattern(naming.immParentPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	expand := map[string]namePattern{"ImmPath": immPath}
+
+	pathB, err := compilePattern(naming.pathB, expand)
+	if err != nil {
+		return nil, err
+	}
+	ifsName, err := compilePattern(naming.ifsName, expand)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un path con varios DASIP toma el menor, para que el resultado sea estable
+	dasips := make(map[string]string)
+	if config.Dasip != nil {
+		keys := make([]string, 0, len(config.Dasip.DasipMapping))
+		for key := range config.Dasip.DasipMapping {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, exists := dasips[config.Dasip.DasipMapping[key]]; !exists {
+				dasips[config.Dasip.DasipMapping[key]] = key
+			}
+		}
+	}
+
+	return &importer{
+		naming:    naming,
+		pathB:     pathB,
+		ifsName:   ifsName,
+		imm:       make(map[string][]*immEntry),
+		terminals: make(map[string]bayTerminal),
+		breakers:  make(map[string]*Breaker),
+		dasips:    dasips,
+	}, nil
+}
+
+// indexIMM indexa los elementos IMM por path y los terminales de los breakers
+// por la medición que enlazan
+func (im *importer) indexIMM(xdf XDF) {
+	im.hasIMM = true
+	for parentIdx, parent := range xdf.Instances.Parents {
+		for idx, element := range parent.Elements {
+			var name string
+			switch e := element.(type) {
+			case *Analog:
+				name = e.Name
+			case *Discrete:
+				name = e.Name
+			case *Breaker:
+				name = e.Name
+				im.indexBreaker(parent.Path, e.Name)
+			case LinkedTerminal:
+				for _, link := range e.Links {
+					im.terminals[link.PathB] = bayTerminal{name: e.Name, breakerPath: parent.Path}
+				}
+				continue
+			default:
+				continue
+			}
+
+			key := parent.Path + "/" + name
+			im.imm[key] = append(im.imm[key], &immEntry{parent: parentIdx, index: idx, element: element})
+		}
+	}
+}
+
+// indexBreaker registra la plantilla del breaker por el path del Parent que
+// contiene sus terminales (naming.breaker_parent_path)
+func (im *importer) indexBreaker(immPath, name string) {
+	template, found := GetTemplate(name)
+	if !found || template.Breaker == nil {
+		return
+	}
+
+	data := NamingData{"ImmPath": immPath, "DisplayName": name, "Breaker": name}
+	if path, err := im.naming.BreakerParentPath(data); err == nil {
+		im.breakers[path] = template.Breaker
+	}
+}
+
+// dasIP retorna el DASIP cuyo mapeo genera el path IFS indicado. El path por
+// defecto corresponde a un DASIP vacío
+func (im *importer) dasIP(parentPath string) string {
+	if dasIP, ok := im.dasips[parentPath]; ok {
+		return dasIP
+	}
+	if config.GetIfsParentPath("") != parentPath {
+		log.Printf("[WARN] El path IFS '%s' no está en dasip_config.yaml; sus filas quedan sin DASIP", parentPath)
+	}
+	return ""
+}
+
+// importPoint reconstruye la fila de un punto IFS
+func (im *importer) importPoint(point *IfsPoint) (*importedRow, error) {
+	if point.Link_IfsPointLinksToInfo == nil {
+		return nil, fmt.Errorf("sin Link_IfsPointLinksToInfo")
+	}
+
+	candidates := im.candidates(point)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("el nombre y el PathB '%s' no siguen las reglas de naming", point.Link_IfsPointLinksToInfo.PathB)
+	}
+
+	// Con el archivo IMM se prefiere la interpretación cuyo elemento existe
+	chosen := candidates[0]
+	for _, c := range candidates {
+		if im.consistentWithIMM(c) {
+			chosen = c
+			break
+		}
+	}
+
+	row := &importedRow{values: make(map[string]string)}
+	for field, value := range chosen.data {
+		if !derivedFields[field] {
+			row.values[field] = value
+		}
+	}
+
+	if _, hasTemplate := GetTemplate(chosen.element); hasTemplate {
+		row.imm = im.takeIMM(chosen.data)
+	}
+
+	row.values["ELEMENT"] = chosen.element
+	row.values["TYPE"] = typeLabel(chosen.signalType, chosen.element)
+	row.values["SBO"] = point.SelectBefore
+	row.values["MHB"] = point.MonAddrHigh
+	row.values["MMB"] = point.MonAddrMiddle
+	row.values["MLB"] = point.MonAddrLow
+	row.values["CHB"] = point.ConAddrHigh
+	row.values["CMB"] = point.ConAddrMiddle
+	row.values["CLB"] = point.ConAddrLow
+	row.values["AOR"] = elementAOR(chosen.element, row.imm)
+	row.values["TERMINAL"] = im.terminal(chosen)
+	return row, nil
+}
+
+// candidates retorna las interpretaciones del punto con las que path_b e
+// ifs_name generan exactamente su PathB y su nombre
+func (im *importer) candidates(point *IfsPoint) []pointCandidate {
+	var found []pointCandidate
+	im.pathB.match(point.Link_IfsPointLinksToInfo.PathB, NamingData{}, func(data NamingData) bool {
+		for _, part := range displayParts(data) {
+			if c, ok := im.candidate(point, part.data, part.breaker); ok {
+				found = append(found, c)
+			}
+		}
+		return len(found) < maxPointCandidates
+	})
+	return found
+}
+
+// displayPart es una forma de leer el nombre de visualización de un PathB
+type displayPart struct {
+	data    NamingData
+	breaker bool
+}
+
+// displayParts deduce DisplayName, NamePart y PathPart de los campos leídos
+// del PathB. Un PathPart "X/X" puede ser un breaker con nombre X
+func displayParts(data NamingData) []displayPart {
+	var parts []displayPart
+	add := func(display string, breaker bool) {
+		namePart, pathPart := display, display
+		if breaker {
+			namePart = display + "_" + display
+			pathPart = display + "/" + display
+		}
+		d := data
+		for field, value := range map[string]string{"DisplayName": display, "NamePart": namePart, "PathPart": pathPart} {
+			if bound, ok := d[field]; ok {
+				if bound != value {
+					return
+				}
+				continue
+			}
+			d = d.with(field, value)
+		}
+		parts = append(parts, displayPart{data: d, breaker: breaker})
+	}
+
+	var display string
+	switch {
+	case data["PathPart"] != "":
+		display = data["PathPart"]
+	case data["DisplayName"] != "":
+		display = data["DisplayName"]
+	case data["NamePart"] != "":
+		display = data["NamePart"]
+	default:
+		return nil
+	}
+
+	add(display, false)
+	for _, sep := range []string{"/", "_"} {
+		if half := len(display) / 2; len(display)%2 == 1 && display[half:half+1] == sep && display[:half] == display[half+1:] {
+			add(display[:half], true)
+		}
+	}
+	return parts
+}
+
+// candidate completa una interpretación con ELEMENT y TYPE, y la acepta si
+// las reglas de naming generan el mismo nombre y PathB del punto
+func (im *importer) candidate(point *IfsPoint, data NamingData, breaker bool) (pointCandidate, bool) {
+	display := data["DisplayName"]
+	element := display
+	if data["INFO"] == "MvMoment" {
+		element = strings.ReplaceAll(display, " ", "_")
+	}
+
+	if isBreakerElement(element) != breaker {
+		return pointCandidate{}, false
+	}
+
+	for _, option := range signalTypeOptions() {
+		st := option.signalType
+		if st.ConType != point.ConType || st.MonType != point.MonType {
+			continue
+		}
+		typed := data.with("Suffix", st.Suffix).with("ConType", st.ConType).with("MonType", st.MonType)
+
+		var result NamingData
+		im.ifsName.match(point.Name, typed, func(m NamingData) bool {
+			result = m
+			return false
+		})
+		if result == nil {
+			continue
+		}
+
+		immPath, err := im.naming.ImmParentPath(result)
+		if err != nil {
+			return pointCandidate{}, false
+		}
+		result = result.with("ImmPath", immPath)
+
+		// Verificar con las reglas de naming en el sentido normal
+		name, errName := im.naming.IfsName(result)
+		pathB, errPath := im.naming.PathB(result)
+		if errName != nil || errPath != nil || name != point.Name || pathB != point.Link_IfsPointLinksToInfo.PathB {
+			continue
+		}
+
+		return pointCandidate{data: result, element: element, signalType: option.name}, true
+	}
+	return pointCandidate{}, false
+}
+
+// signalTypeOption es un valor de TYPE con su sufijo y tipos IFS
+type signalTypeOption struct {
+	name       string
+	signalType config.SignalType
+}
+
+// signalTypeOptions lista los TYPE de signal_types en orden alfabético y al
+// final el tipo por defecto (nombre vacío)
+func signalTypeOptions() []signalTypeOption {
+	names := make([]string, 0, len(config.Global.Naming.SignalTypes))
+	for name := range config.Global.Naming.SignalTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := make([]signalTypeOption, 0, len(names)+1)
+	for _, name := range names {
+		options = append(options, signalTypeOption{name: name, signalType: config.GetSignalType(name)})
+	}
+	return append(options, signalTypeOption{signalType: config.Global.Naming.DefaultSignalType})
+}
+
+// consistentWithIMM indica si la interpretación coincide con el archivo IMM:
+// un ELEMENT con plantilla genera un elemento IMM con su nombre de visualización
+func (im *importer) consistentWithIMM(c pointCandidate) bool {
+	if !im.hasIMM {
+		return true
+	}
+	_, hasTemplate := GetTemplate(c.element)
+	hasElement := false
+	for _, entry := range im.imm[c.data["ImmPath"]+"/"+c.data["DisplayName"]] {
+		if !entry.used {
+			hasElement = true
+			break
+		}
+	}
+	return hasTemplate == hasElement
+}
+
+// takeIMM marca como usado el siguiente elemento IMM de la fila
+func (im *importer) takeIMM(data NamingData) *immEntry {
+	for _, entry := range im.imm[data["ImmPath"]+"/"+data["DisplayName"]] {
+		if !entry.used {
+			entry.used = true
+			return entry
+		}
+	}
+	return nil
+}
+
+// unusedIMM cuenta los elementos IMM que ninguna fila generó
+func (im *importer) unusedIMM() int {
+	unused := 0
+	for _, entries := range im.imm {
+		for _, entry := range entries {
+			if !entry.used {
+				unused++
+			}
+		}
+	}
+	return unused
+}
+
+// typeLabel elige el valor de TYPE. Para el tipo por defecto se usa MV si la
+// plantilla del ELEMENT es analógica y SP en el resto, salvo que ese valor
+// esté en signal_types con otro sufijo o tipos
+func typeLabel(signalType, elementKey string) string {
+	if signalType != "" {
+		return signalType
+	}
+
+	label := "SP"
+	if template, found := GetTemplate(elementKey); found && template.Analog != nil {
+		label = "MV"
+	}
+	if config.GetSignalType(label) != config.Global.Naming.DefaultSignalType {
+		return ""
+	}
+	return label
+}
+
+// terminal retorna el terminal del breaker al que se enlaza la medición,
+// solo si no es el terminal por defecto
+func (im *importer) terminal(c pointCandidate) string {
+	if !breakerMeasurements[c.element] {
+		return ""
+	}
+
+	terminal, ok := im.terminals[c.data["ImmPath"]+"/"+c.data["DisplayName"]]
+	if !ok {
+		return ""
+	}
+
+	if terminal.name == selectDefaultTerminal(im.breakers[terminal.breakerPath]) {
+		return ""
+	}
+	return terminal.name
+}
+
+// elementAOR retorna el AOR del elemento IMM si la plantilla lo toma de la fila
+func elementAOR(elementKey string, entry *immEntry) string {
+	if entry == nil {
+		return ""
+	}
+	template, _ := GetTemplate(elementKey)
+
+	var templateAOR, aor string
+	switch e := entry.element.(type) {
+	case *Analog:
+		aor = e.AreaOfResponsibilityId
+		if template.Analog != nil {
+			templateAOR = template.Analog.AreaOfResponsibilityId
+		}
+	case *Discrete:
+		aor = e.AreaOfResponsibilityId
+		if template.Discrete != nil {
+			templateAOR = template.Discrete.AreaOfResponsibilityId
+		}
+	case *Breaker:
+		aor = e.AreaOfResponsibilityId
+		if template.Breaker != nil {
+			templateAOR = template.Breaker.AreaOfResponsibilityId
+		}
+	}
+
+	if templateAOR != "" && !strings.Contains(templateAOR, "{AOR") {
+		return ""
+	}
+	return aor
+}
+
+// fillAOR completa el AOR de las filas sin elemento IMM con --aor o, si no
+// se indicó, con el primer AOR recuperado del archivo IMM
+func (im *importer) fillAOR(rows []*importedRow, aor string) {
+	if aor == "" {
+		for _, row := range rows {
+			if row.values["AOR"] != "" {
+				aor = row.values["AOR"]
+				break
+			}
+		}
+	}
+
+	missing := 0
+	for _, row := range rows {
+		if row.values["AOR"] == "" {
+			row.values["AOR"] = aor
+			missing++
+		}
+	}
+	if missing > 0 && aor == "" {
+		log.Printf("[WARN] %d filas sin AOR (use --aor)", missing)
+	}
+}
+
+// importedColumns retorna importColumns más las demás columnas usadas por las
+// reglas de naming y las columnas requeridas que falten
+func importedColumns(rows []*importedRow) []string {
+	columns := append([]string(nil), importColumns...)
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+
+	var extra []string
+	for _, row := range rows {
+		for column := range row.values {
+			if !known[column] {
+				known[column] = true
+				extra = append(extra, column)
+			}
+		}
+	}
+	sort.Strings(extra)
+	columns = append(columns, extra...)
+
+	for _, column := range config.Global.Validation.RequiredColumns {
+		if !known[column] {
+			known[column] = true
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// importOrder ordena las filas para que csv-xml agrupe los elementos igual
+// que en los XML originales: se respeta el orden dentro de cada Parent IFS e
+// IMM y el orden de aparición de los Parent. Entre filas sin restricción se
+// conserva el orden del archivo IFS
+func importOrder(rows []*importedRow) []*importedRow {
+	after := make([][]int, len(rows)) // Fila -> filas que deben ir después
+	pending := make([]int, len(rows)) // Fila -> filas que deben ir antes
+	addSequences := func(groups [][]int) {
+		for g, group := range groups {
+			for i := 1; i < len(group); i++ {
+				after[group[i-1]] = append(after[group[i-1]], group[i])
+				pending[group[i]]++
+			}
+			if g > 0 {
+				after[groups[g-1][0]] = append(after[groups[g-1][0]], group[0])
+				pending[group[0]]++
+			}
+		}
+	}
+
+	// Parents IFS, en el orden del archivo
+	var ifsGroups [][]int
+	for i, row := range rows {
+		if i == 0 || row.ifsParent != rows[i-1].ifsParent {
+			ifsGroups = append(ifsGroups, nil)
+		}
+		ifsGroups[len(ifsGroups)-1] = append(ifsGroups[len(ifsGroups)-1], i)
+	}
+	addSequences(ifsGroups)
+
+	// Parents IMM, ordenados por su posición en el archivo IMM
+	var withIMM []int
+	for i, row := range rows {
+		if row.imm != nil {
+			withIMM = append(withIMM, i)
+		}
+	}
+	sort.Slice(withIMM, func(a, b int) bool {
+		ea, eb := rows[withIMM[a]].imm, rows[withIMM[b]].imm
+		if ea.parent != eb.parent {
+			return ea.parent < eb.parent
+		}
+		return ea.index < eb.index
+	})
+	var immGroups [][]int
+	for i, idx := range withIMM {
+		if i == 0 || rows[idx].imm.parent != rows[withIMM[i-1]].imm.parent {
+			immGroups = append(immGroups, nil)
+		}
+		immGroups[len(immGroups)-1] = append(immGroups[len(immGroups)-1], idx)
+	}
+	addSequences(immGroups)
+
+	// Orden topológico eligiendo siempre la fila lista con menor posición IFS
+	var ready []int
+	for i := range rows {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	ordered := make([]*importedRow, 0, len(rows))
+	for len(ready) > 0 {
+		next := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, rows[next])
+		for _, idx := range after[next] {
+			if pending[idx]--; pending[idx] == 0 {
+				pos := sort.SearchInts(ready, idx)
+				ready = append(ready, 0)
+				copy(ready[pos+1:], ready[pos:])
+				ready[pos] = idx
+			}
+		}
+	}
+
+	if len(ordered) < len(rows) {
+		log.Printf("[WARN] El orden de los archivos IFS e IMM no es compatible; se usa el orden del IFS")
+		return rows
+	}
+	return ordered
+}
@@ -0,0 +1,230 @@
+// pkg/xmlcreator/lint.go
+package xmlcreator
+
+import (
+	"encoding/json"
+	"fmt"
+	"goScadaSur/pkg/config"
+	"goScadaSur/pkg/fileio"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Severidades de un diagnóstico
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// addressColumns son las columnas de dirección IFS (un byte cada una)
+var addressColumns = []string{"MHB", "MMB", "MLB", "CHB", "CMB", "CLB"}
+
+// Diagnostic es un problema encontrado al revisar un archivo de entrada
+type Diagnostic struct {
+	Row      int    `json:"row"`
+	Column   string `json:"column,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+}
+
+// LintReport es el resultado de revisar un dataset sin generar XML
+type LintReport struct {
+	File        string       `json:"file"`
+	Sheet       string       `json:"sheet,omitempty"`
+	Rows        int          `json:"rows"`
+	Errors      int          `json:"errors"`
+	Warnings    int          `json:"warnings"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// add registra un diagnóstico y actualiza los contadores
+func (r *LintReport) add(d Diagnostic) {
+	if d.Severity == SeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+	r.Diagnostics = append(r.Diagnostics, d)
+}
+
+// LintFile revisa un archivo de entrada con las mismas reglas que la
+// generación de XML (cabeceras, validation.rules, plantillas, mapeo DASIP,
+// direcciones y colisiones de nombres o direcciones IFS) sin escribir ningún
+// archivo
+func LintFile(inputFilePath string, opts fileio.ReadOptions) (*LintReport, error) {
+	if opts.BufferSize == 0 {
+		opts.BufferSize = config.Global.Processing.BufferSize
+	}
+	if opts.HeaderColumns == nil {
+		opts.HeaderColumns = config.Global.Validation.RequiredColumns
+		opts.HeaderSearchRows = config.Global.Files.HeaderSearchRows
+	}
+	reader, header, headerMap, err := fileio.OpenData(inputFilePath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivo: %w", err)
+	}
+	defer reader.Close()
+
+	report := &LintReport{File: inputFilePath, Sheet: opts.Sheet, Diagnostics: []Diagnostic{}}
+
+	// Sin las columnas requeridas las demás verificaciones no son útiles
+	var missing []string
+	for _, col := range config.Global.Validation.RequiredColumns {
+		if _, ok := headerMap[col]; !ok {
+			missing = append(missing, col)
+		}
+	}
+	if len(missing) > 0 {
+		for _, col := range missing {
+			report.add(Diagnostic{Row: header.Number, Column: col, Severity: SeverityError, Check: "header", Message: "columna requerida faltante"})
+		}
+		return report, nil
+	}
+
+	rules, err := NewValidationRules(config.Global.Validation)
+	if err != nil {
+		return nil, err
+	}
+	naming, err := NewNamingRules(config.Global.Naming)
+	if err != nil {
+		return nil, err
+	}
+	linter := &rowLinter{
+		report:     report,
+		rules:      rules,
+		naming:     naming,
+		headerMap:  headerMap,
+		collisions: newCollisionDetector(),
+	}
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo archivo: %w", err)
+		}
+		if len(row.Values) == 0 {
+			continue
+		}
+		report.Rows++
+		linter.check(row.Number, row.Values)
+	}
+
+	// Nombres y direcciones repetidos con la misma verificación que csv-xml
+	for _, c := range linter.collisions.sorted() {
+		v := c.violation()
+		report.add(Diagnostic{Row: v.Row, Column: v.Column, Value: v.Value, Severity: SeverityError, Check: v.Rule, Message: v.Message})
+	}
+	sort.SliceStable(report.Diagnostics, func(i, j int) bool {
+		return report.Diagnostics[i].Row < report.Diagnostics[j].Row
+	})
+
+	return report, nil
+}
+
+// rowLinter aplica las verificaciones fila a fila
+type rowLinter struct {
+	report     *LintReport
+	rules      *ValidationRules
+	naming     *NamingRules
+	headerMap  map[string]int
+	collisions *collisionDetector
+}
+
+// check revisa una fila de datos
+func (l *rowLinter) check(rowNum int, row []string) {
+	value := func(column string) string {
+		return fileio.GetCellValueOrDefault(row, l.headerMap, column, "")
+	}
+
+	// Reglas de validation.rules
+	for _, v := range l.rules.Check(rowNum, row, l.headerMap) {
+		l.report.add(Diagnostic{Row: v.Row, Column: v.Column, Value: v.Value, Severity: SeverityError, Check: "rule:" + v.Rule, Message: v.Message})
+	}
+
+	// Plantilla del elemento
+	elementKey := value("ELEMENT")
+	if elementKey == "" {
+		l.report.add(Diagnostic{Row: rowNum, Column: "ELEMENT", Severity: SeverityWarning, Check: "element", Message: "ELEMENT vacío: la fila se omite"})
+		return
+	}
+	if _, ok := GetTemplate(elementKey); !ok {
+		l.report.add(Diagnostic{Row: rowNum, Column: "ELEMENT", Value: elementKey, Severity: SeverityWarning, Check: "template", Message: "plantilla no encontrada: no se genera elemento IMM"})
+	}
+
+	// Mapeo DASIP
+	dasIP := value("DASIP")
+	if _, ok := config.LookupIfsParentPath(dasIP); !ok {
+		l.report.add(Diagnostic{Row: rowNum, Column: "DASIP", Value: dasIP, Severity: SeverityWarning, Check: "dasip",
+			Message: fmt.Sprintf("DASIP sin mapeo: se usa %s", config.GetIfsParentPath(dasIP))})
+	}
+
+	l.checkAddresses(rowNum, value)
+
+	// Punto IFS de la fila, para detectar colisiones
+	data, err := rowNamingData(elementKey, row, l.headerMap, l.naming)
+	var point *IfsPoint
+	if err == nil {
+		point, err = createIfsPoint(row, l.headerMap, data, l.naming, isBreakerElement(elementKey))
+	}
+	if err != nil {
+		l.report.add(Diagnostic{Row: rowNum, Severity: SeverityError, Check: "naming", Message: err.Error()})
+		return
+	}
+	l.collisions.add(config.GetIfsParentPath(dasIP), point, rowNum)
+}
+
+// checkAddresses verifica que cada byte de dirección sea un entero 0-255.
+// Las columnas con regla en validation.rules ya fueron verificadas
+func (l *rowLinter) checkAddresses(rowNum int, value func(string) string) {
+	for _, column := range addressColumns {
+		val := value(column)
+		if val == "" {
+			continue
+		}
+		if _, hasRule := config.Global.Validation.Rules[column]; hasRule {
+			continue
+		}
+		if n, err := strconv.Atoi(val); err != nil || n < 0 || n > 255 {
+			l.report.add(Diagnostic{Row: rowNum, Column: column, Value: val, Severity: SeverityError, Check: "address", Message: "se esperaba un entero entre 0 y 255"})
+		}
+	}
+}
+
+// Print escribe los diagnósticos como tabla
+func (r *LintReport) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(r.Diagnostics) > 0 {
+		fmt.Fprintln(tw, "FILA\tSEVERIDAD\tVERIFICACIÓN\tCOLUMNA\tVALOR\tMENSAJE")
+		for _, d := range r.Diagnostics {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", d.Row, d.Severity, d.Check, dash(d.Column), dash(d.Value), d.Message)
+		}
+		fmt.Fprintln(tw)
+	}
+	fmt.Fprintf(tw, "%s: %d filas, %d errores, %d advertencias\n", r.File, r.Rows, r.Errors, r.Warnings)
+	tw.Flush()
+}
+
+// WriteJSON escribe el reporte en JSON
+func (r *LintReport) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando reporte: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// dash reemplaza un texto vacío por "-" en las tablas
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,171 @@
+// pkg/xmlcreator/namepattern.go
+package xmlcreator
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode/utf8"
+)
+
+// maxPatternMatches limita las asignaciones que se prueban por texto, para
+// que una plantilla con campos contiguos no se vuelva exponencial
+const maxPatternMatches = 256
+
+// namePattern es una plantilla de nombres preparada para leerla al revés:
+// a partir de un nombre o path generado, recuperar los campos de la fila.
+// Solo admite texto fijo, campos ({{.B1}}) y bloques {{if .CAMPO}}...{{end}}
+type namePattern []patternNode
+
+// patternNode es un texto fijo, un campo o un bloque opcional
+type patternNode struct {
+	text     string      // Texto fijo
+	field    string      // Campo de NamingData
+	cond     string      // Campo que activa el bloque opcional
+	optional namePattern // Contenido del bloque opcional
+}
+
+// compilePattern convierte una plantilla de nombres en un namePattern. Los
+// campos de expand se reemplazan por su propio patrón (p. ej. ImmPath por
+// imm_parent_path), para recuperar también los campos que contienen
+func compilePattern(tmpl *template.Template, expand map[string]namePattern) (namePattern, error) {
+	pattern, err := compileNodes(tmpl.Tree.Root, expand)
+	if err != nil {
+		return nil, fmt.Errorf("plantilla de nombres '%s' no se puede invertir: %w", tmpl.Name(), err)
+	}
+	return pattern, nil
+}
+
+// compileNodes compila una lista de nodos de la plantilla
+func compileNodes(list *parse.ListNode, expand map[string]namePattern) (namePattern, error) {
+	var pattern namePattern
+	if list == nil {
+		return pattern, nil
+	}
+
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			pattern = append(pattern, patternNode{text: string(n.Text)})
+
+		case *parse.ActionNode:
+			field, ok := pipeField(n.Pipe)
+			if !ok {
+				return nil, fmt.Errorf("acción '%s' no soportada", n)
+			}
+			if sub, ok := expand[field]; ok {
+				pattern = append(pattern, sub...)
+			} else {
+				pattern = append(pattern, patternNode{field: field})
+			}
+
+		case *parse.IfNode:
+			cond, ok := pipeField(n.Pipe)
+			if !ok || n.ElseList != nil {
+				return nil, fmt.Errorf("condición '%s' no soportada", n)
+			}
+			body, err := compileNodes(n.List, expand)
+			if err != nil {
+				return nil, err
+			}
+			pattern = append(pattern, patternNode{cond: cond, optional: body})
+
+		default:
+			return nil, fmt.Errorf("construcción '%s' no soportada", node)
+		}
+	}
+	return pattern, nil
+}
+
+// pipeField retorna el campo de un pipeline de la forma {{.CAMPO}}
+func pipeField(pipe *parse.PipeNode) (string, bool) {
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return "", false
+	}
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok || len(field.Ident) != 1 {
+		return "", false
+	}
+	return field.Ident[0], true
+}
+
+// match recorre las asignaciones de campos con las que el patrón genera
+// exactamente text, empezando por los valores más cortos. Los campos de bound
+// ya tienen valor y deben coincidir. yield retorna false para detener la búsqueda
+func (p namePattern) match(text string, bound NamingData, yield func(NamingData) bool) {
+	count := 0
+	matchNodes(p, text, bound, func(rest string, data NamingData) bool {
+		if rest != "" {
+			return true
+		}
+		count++
+		return yield(data) && count < maxPatternMatches
+	})
+}
+
+// matchNodes compara los nodos con el inicio de text y continúa con next
+// sobre el resto. Retorna false si la búsqueda se detuvo
+func matchNodes(nodes namePattern, text string, bound NamingData, next func(string, NamingData) bool) bool {
+	if len(nodes) == 0 {
+		return next(text, bound)
+	}
+	node, rest := nodes[0], nodes[1:]
+	cont := func(text string, data NamingData) bool {
+		return matchNodes(rest, text, data, next)
+	}
+
+	switch {
+	case node.optional != nil:
+		// Con el bloque, el campo de la condición no puede quedar vacío
+		withBlock := matchNodes(node.optional, text, bound, func(text string, data NamingData) bool {
+			if value, ok := data[node.cond]; ok && value == "" {
+				return true
+			}
+			return cont(text, data)
+		})
+		if !withBlock {
+			return false
+		}
+		if value, ok := bound[node.cond]; ok {
+			if value != "" {
+				return true
+			}
+			return cont(text, bound)
+		}
+		return cont(text, bound.with(node.cond, ""))
+
+	case node.field != "":
+		if value, ok := bound[node.field]; ok {
+			if !strings.HasPrefix(text, value) {
+				return true
+			}
+			return cont(text[len(value):], bound)
+		}
+		for i := 0; i <= len(text); i++ {
+			if i < len(text) && !utf8.RuneStart(text[i]) {
+				continue
+			}
+			if !cont(text[i:], bound.with(node.field, text[:i])) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		if !strings.HasPrefix(text, node.text) {
+			return true
+		}
+		return cont(text[len(node.text):], bound)
+	}
+}
+
+// with retorna una copia de los datos con un campo más
+func (d NamingData) with(field, value string) NamingData {
+	data := make(NamingData, len(d)+1)
+	for k, v := range d {
+		data[k] = v
+	}
+	data[field] = value
+	return data
+}
@@ -0,0 +1,88 @@
+// pkg/xmlcreator/naming.go
+package xmlcreator
+
+import (
+	"fmt"
+	"goScadaSur/pkg/config"
+	"goScadaSur/pkg/fileio"
+	"strings"
+	"text/template"
+)
+
+// NamingRules contiene las plantillas compiladas de nombres y paths
+type NamingRules struct {
+	ifsName           *template.Template
+	pathB             *template.Template
+	immParentPath     *template.Template
+	breakerParentPath *template.Template
+}
+
+// NamingData son los valores disponibles en las plantillas de nombres: todas
+// las columnas de la fila (en mayúsculas, p. ej. {{.B1}}) más los campos
+// calculados (DisplayName, NamePart, PathPart, Suffix, ConType, MonType,
+// ImmPath y Breaker)
+type NamingData map[string]string
+
+// NewNamingRules compila las plantillas de la sección naming de la configuración
+func NewNamingRules(cfg config.NamingConfig) (*NamingRules, error) {
+	rules := &NamingRules{}
+
+	templates := []struct {
+		name   string
+		text   string
+		target **template.Template
+	}{
+		{"ifs_name", cfg.IfsName, &rules.ifsName},
+		{"path_b", cfg.PathB, &rules.pathB},
+		{"imm_parent_path", cfg.ImmParentPath, &rules.immParentPath},
+		{"breaker_parent_path", cfg.BreakerParentPath, &rules.breakerParentPath},
+	}
+
+	for _, t := range templates {
+		tmpl, err := template.New(t.name).Option("missingkey=zero").Parse(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("plantilla de nombres '%s' inválida: %w", t.name, err)
+		}
+		*t.target = tmpl
+	}
+
+	return rules, nil
+}
+
+// newNamingData construye los datos de plantilla a partir de una fila
+func newNamingData(row []string, headerMap map[string]int) NamingData {
+	data := make(NamingData, len(headerMap)+8)
+	for column, idx := range headerMap {
+		data[column] = fileio.GetCellValue(row, idx)
+	}
+	return data
+}
+
+// IfsName genera el nombre del punto IFS
+func (n *NamingRules) IfsName(data NamingData) (string, error) {
+	return render(n.ifsName, data)
+}
+
+// PathB genera el PathB con el que el punto IFS se enlaza a su elemento IMM
+func (n *NamingRules) PathB(data NamingData) (string, error) {
+	return render(n.pathB, data)
+}
+
+// ImmParentPath genera el path del Parent IMM de la fila
+func (n *NamingRules) ImmParentPath(data NamingData) (string, error) {
+	return render(n.immParentPath, data)
+}
+
+// BreakerParentPath genera el path del Parent que contiene los terminales del breaker
+func (n *NamingRules) BreakerParentPath(data NamingData) (string, error) {
+	return render(n.breakerParentPath, data)
+}
+
+// render ejecuta una plantilla de nombres
+func render(tmpl *template.Template, data NamingData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error evaluando plantilla '%s': %w", tmpl.Name(), err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,134 @@
+// pkg/xmlcreator/placeholders.go
+package xmlcreator
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"goScadaSur/pkg/fileio"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern reconoce {NOMBRE} y {NOMBRE|valor por defecto}
+var placeholderPattern = regexp.MustCompile(`\{([^{}|]+)(?:\|([^{}]*))?\}`)
+
+// xmlNameType se omite al recorrer las estructuras (no es un atributo)
+var xmlNameType = reflect.TypeOf(xml.Name{})
+
+// PlaceholderError describe un placeholder que no pudo resolverse
+type PlaceholderError struct {
+	Row         int
+	Field       string
+	Placeholder string
+}
+
+func (e *PlaceholderError) Error() string {
+	return fmt.Sprintf("fila %d: placeholder '%s' desconocido en %s", e.Row, e.Placeholder, e.Field)
+}
+
+// PlaceholderContext contiene los valores disponibles para sustituir en una fila
+type PlaceholderContext struct {
+	RowNum int
+	values map[string]string
+}
+
+// NewPlaceholderContext construye el contexto de sustitución de una fila.
+// Expone todas las columnas de entrada más {AOR} y {DISPLAY_NAME}
+func NewPlaceholderContext(rowNum int, row []string, headerMap map[string]int, displayName string) *PlaceholderContext {
+	values := make(map[string]string, len(headerMap)+2)
+	for column, idx := range headerMap {
+		values[column] = fileio.GetCellValue(row, idx)
+	}
+
+	values["AOR"] = fileio.GetCellValueOrDefault(row, headerMap, "AOR", "")
+	values["DISPLAY_NAME"] = displayName
+
+	return &PlaceholderContext{
+		RowNum: rowNum,
+		values: values,
+	}
+}
+
+// Resolve sustituye los placeholders de un texto. Un placeholder con valor por
+// defecto ({SBO|0}) usa el defecto cuando la columna falta o está vacía
+func (c *PlaceholderContext) Resolve(field, text string) (string, error) {
+	if !strings.Contains(text, "{") {
+		return text, nil
+	}
+
+	var errs []error
+	resolved := placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := placeholderPattern.FindStringSubmatch(match)
+		name := strings.TrimSpace(parts[1])
+		hasDefault := strings.Contains(match, "|")
+
+		value, exists := c.values[name]
+		if exists && value != "" {
+			return value
+		}
+		if hasDefault {
+			return parts[2]
+		}
+		if exists {
+			return ""
+		}
+
+		errs = append(errs, &PlaceholderError{Row: c.RowNum, Field: field, Placeholder: match})
+		return match
+	})
+
+	return resolved, errors.Join(errs...)
+}
+
+// SubstitutePlaceholders recorre todos los atributos string de un ElementDef
+// (ya copiado) y sustituye sus placeholders
+func SubstitutePlaceholders(element *ElementDef, ctx *PlaceholderContext) error {
+	return substituteValue(reflect.ValueOf(element).Elem(), "", ctx)
+}
+
+// substituteValue aplica la sustitución de forma recursiva sobre un valor
+func substituteValue(v reflect.Value, field string, ctx *PlaceholderContext) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return substituteValue(v.Elem(), field, ctx)
+
+	case reflect.Struct:
+		if v.Type() == xmlNameType {
+			return nil
+		}
+		var errs []error
+		for i := 0; i < v.NumField(); i++ {
+			structField := v.Type().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			name := structField.Name
+			if field != "" {
+				name = field + "." + name
+			}
+			errs = append(errs, substituteValue(v.Field(i), name, ctx))
+		}
+		return errors.Join(errs...)
+
+	case reflect.Slice:
+		var errs []error
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, substituteValue(v.Index(i), fmt.Sprintf("%s[%d]", field, i), ctx))
+		}
+		return errors.Join(errs...)
+
+	case reflect.String:
+		resolved, err := ctx.Resolve(field, v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(resolved)
+	}
+
+	return nil
+}
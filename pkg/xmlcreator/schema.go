@@ -0,0 +1,1073 @@
+// pkg/xmlcreator/schema.go
+package xmlcreator
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"goScadaSur/pkg/fileio"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrSchema indica que un XDF no cumple el esquema
+var ErrSchema = errors.New("el XDF no cumple el esquema")
+
+// embeddedSchema es el esquema XDF incluido en el binario (xml.schema vacío)
+//
+//go:embed xdf.xsd
+var embeddedSchema []byte
+
+const (
+	xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+	unbounded    = -1
+)
+
+// Schema es un esquema XSD compilado para validar documentos XDF. Se admite
+// el subconjunto de XSD que usa el esquema XDF: elementos globales y locales
+// (type, ref o tipo anónimo), complexType con sequence, choice, any y
+// anyAttribute, atributos con use="required", y simpleType por restricción
+// con enumeration, pattern, length, minLength, maxLength, minInclusive y
+// maxInclusive sobre los tipos predefinidos comunes. Los espacios de nombres
+// de los documentos no se comparan
+type Schema struct {
+	Source   string
+	elements map[string]*schemaElement
+}
+
+// SchemaError es una violación del esquema en un documento
+type SchemaError struct {
+	Line    int    `json:"line"`
+	Path    string `json:"path"` // p. ej. /XDF/Instances/Parent[Path=...]/IfsPoint[Name=...]
+	Message string `json:"message"`
+}
+
+func (e SchemaError) Error() string {
+	return fmt.Sprintf("línea %d: %s: %s", e.Line, e.Path, e.Message)
+}
+
+// schemaElement es la declaración de un elemento con su tipo
+type schemaElement struct {
+	name    string
+	complex *complexType
+	simple  *simpleType
+}
+
+// complexType son los atributos y el modelo de contenido de un elemento
+type complexType struct {
+	attributes   []*schemaAttribute
+	anyAttribute bool
+	content      *particle // nil si el elemento debe estar vacío
+}
+
+// schemaAttribute es la declaración de un atributo
+type schemaAttribute struct {
+	name     string
+	required bool
+	typ      *simpleType
+}
+
+// particleKind es el tipo de una partícula del modelo de contenido
+type particleKind int
+
+const (
+	particleElement particleKind = iota
+	particleSequence
+	particleChoice
+	particleAny
+)
+
+// particle es un elemento, una secuencia, una elección o un comodín con su
+// número de ocurrencias (max es unbounded si no tiene límite)
+type particle struct {
+	kind     particleKind
+	element  *schemaElement
+	children []*particle
+	min, max int
+}
+
+// simpleType es un tipo predefinido o una restricción de otro tipo simple.
+// Los patrones de una misma restricción se combinan con "o"
+type simpleType struct {
+	builtin      string
+	base         *simpleType
+	enumeration  []string
+	pattern      *regexp.Regexp
+	patternText  string
+	minLength    int
+	maxLength    int // -1 sin límite
+	minInclusive *float64
+	maxInclusive *float64
+}
+
+// builtinTypes son los tipos predefinidos de XSD soportados y su validación
+var builtinTypes = map[string]func(string) bool{
+	"anySimpleType":      func(string) bool { return true },
+	"string":             func(string) bool { return true },
+	"normalizedString":   func(string) bool { return true },
+	"token":              func(string) bool { return true },
+	"language":           func(string) bool { return true },
+	"Name":               func(string) bool { return true },
+	"NCName":             func(string) bool { return true },
+	"anyURI":             func(string) bool { return true },
+	"boolean":            func(v string) bool { return v == "true" || v == "false" || v == "1" || v == "0" },
+	"decimal":            isFloat,
+	"double":             isFloat,
+	"float":              isFloat,
+	"integer":            regexp.MustCompile(`^[+-]?[0-9]+$`).MatchString,
+	"nonNegativeInteger": regexp.MustCompile(`^\+?[0-9]+$`).MatchString,
+	"positiveInteger":    regexp.MustCompile(`^\+?0*[1-9][0-9]*$`).MatchString,
+	"long":               isInt(64),
+	"int":                isInt(32),
+	"short":              isInt(16),
+	"byte":               isInt(8),
+	"unsignedLong":       isUint(64),
+	"unsignedInt":        isUint(32),
+	"unsignedShort":      isUint(16),
+	"unsignedByte":       isUint(8),
+}
+
+// stringTypes son los tipos predefinidos que conservan los espacios
+var stringTypes = map[string]bool{"anySimpleType": true, "string": true, "normalizedString": true}
+
+func isFloat(v string) bool {
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+func isInt(bits int) func(string) bool {
+	return func(v string) bool {
+		_, err := strconv.ParseInt(strings.TrimPrefix(v, "+"), 10, bits)
+		return err == nil
+	}
+}
+
+func isUint(bits int) func(string) bool {
+	return func(v string) bool {
+		_, err := strconv.ParseUint(strings.TrimPrefix(v, "+"), 10, bits)
+		return err == nil
+	}
+}
+
+// ===================================================================================
+// CARGA DEL ESQUEMA
+// ===================================================================================
+
+// LoadSchema carga un esquema XSD desde un archivo, o el esquema XDF
+// incluido si path está vacío
+func LoadSchema(path string) (*Schema, error) {
+	var root xmlNode
+	source := path
+	if path == "" {
+		source = "xdf.xsd incluido"
+		if err := xml.Unmarshal(embeddedSchema, &root); err != nil {
+			return nil, fmt.Errorf("error leyendo %s: %w", source, err)
+		}
+	} else if err := fileio.ReadXML(path, &root); err != nil {
+		return nil, fmt.Errorf("error leyendo esquema: %w", err)
+	}
+
+	schema, err := compileSchema(root)
+	if err != nil {
+		return nil, fmt.Errorf("esquema '%s': %w", source, err)
+	}
+	schema.Source = source
+	return schema, nil
+}
+
+// schemaCompiler resuelve las referencias entre las definiciones globales
+type schemaCompiler struct {
+	prefixes     map[string]string // Prefijo -> espacio de nombres
+	complexNodes map[string]xmlNode
+	simpleNodes  map[string]xmlNode
+	elementNodes map[string]xmlNode
+	complexTypes map[string]*complexType
+	simpleTypes  map[string]*simpleType
+	elements     map[string]*schemaElement
+}
+
+// compileSchema compila el elemento xs:schema
+func compileSchema(root xmlNode) (*Schema, error) {
+	if root.XMLName.Space != xsdNamespace || root.XMLName.Local != "schema" {
+		return nil, fmt.Errorf("el elemento raíz debe ser xs:schema")
+	}
+
+	c := &schemaCompiler{
+		prefixes:     make(map[string]string),
+		complexNodes: make(map[string]xmlNode),
+		simpleNodes:  make(map[string]xmlNode),
+		elementNodes: make(map[string]xmlNode),
+		complexTypes: make(map[string]*complexType),
+		simpleTypes:  make(map[string]*simpleType),
+		elements:     make(map[string]*schemaElement),
+	}
+	for _, a := range root.Attrs {
+		if a.Name.Space == "xmlns" {
+			c.prefixes[a.Name.Local] = a.Value
+		} else if a.Name.Space == "" && a.Name.Local == "xmlns" {
+			c.prefixes[""] = a.Value
+		}
+	}
+
+	definitions := map[string]map[string]xmlNode{
+		"complexType": c.complexNodes,
+		"simpleType":  c.simpleNodes,
+		"element":     c.elementNodes,
+	}
+	for _, node := range root.Children {
+		if node.XMLName.Local == "annotation" {
+			continue
+		}
+		defs, ok := definitions[node.XMLName.Local]
+		if !ok {
+			return nil, fmt.Errorf("xs:%s no soportado", node.XMLName.Local)
+		}
+		name := node.attr("name")
+		if name == "" {
+			return nil, fmt.Errorf("xs:%s global sin name", node.XMLName.Local)
+		}
+		defs[name] = node
+	}
+
+	schema := &Schema{elements: make(map[string]*schemaElement)}
+	for name := range c.elementNodes {
+		element, err := c.globalElement(name)
+		if err != nil {
+			return nil, err
+		}
+		schema.elements[name] = element
+	}
+	if len(schema.elements) == 0 {
+		return nil, fmt.Errorf("no declara ningún elemento global")
+	}
+	return schema, nil
+}
+
+// globalElement retorna un elemento global, compilándolo una sola vez
+func (c *schemaCompiler) globalElement(name string) (*schemaElement, error) {
+	if element, ok := c.elements[name]; ok {
+		return element, nil
+	}
+	node, ok := c.elementNodes[name]
+	if !ok {
+		return nil, fmt.Errorf("elemento '%s' no declarado", name)
+	}
+	// Se registra antes de compilar el tipo para admitir recursión
+	element := &schemaElement{name: name}
+	c.elements[name] = element
+	if err := c.elementType(element, node); err != nil {
+		return nil, err
+	}
+	return element, nil
+}
+
+// elementType compila el tipo de un elemento: type, tipo anónimo o anyType
+func (c *schemaCompiler) elementType(element *schemaElement, node xmlNode) error {
+	if typeName := node.attr("type"); typeName != "" {
+		complex, simple, err := c.resolveType(typeName)
+		if err != nil {
+			return fmt.Errorf("elemento '%s': %w", element.name, err)
+		}
+		element.complex, element.simple = complex, simple
+		return nil
+	}
+
+	for _, child := range node.Children {
+		var err error
+		switch child.XMLName.Local {
+		case "annotation":
+			continue
+		case "complexType":
+			element.complex, err = c.compileComplexType(child, &complexType{})
+		case "simpleType":
+			element.simple, err = c.compileSimpleType(child)
+		default:
+			err = fmt.Errorf("xs:%s no soportado", child.XMLName.Local)
+		}
+		if err != nil {
+			return fmt.Errorf("elemento '%s': %w", element.name, err)
+		}
+		return nil
+	}
+
+	// Sin tipo, el elemento admite cualquier contenido
+	element.complex = &complexType{
+		anyAttribute: true,
+		content:      &particle{kind: particleAny, min: 0, max: unbounded},
+	}
+	return nil
+}
+
+// resolveType retorna el tipo complejo o simple de un QName
+func (c *schemaCompiler) resolveType(qname string) (*complexType, *simpleType, error) {
+	prefix, local := "", qname
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		prefix, local = qname[:i], qname[i+1:]
+	}
+	if c.prefixes[prefix] == xsdNamespace {
+		if local == "anyType" {
+			return &complexType{anyAttribute: true, content: &particle{kind: particleAny, min: 0, max: unbounded}}, nil, nil
+		}
+		if _, ok := builtinTypes[local]; !ok {
+			return nil, nil, fmt.Errorf("tipo xs:%s no soportado", local)
+		}
+		return nil, &simpleType{builtin: local, maxLength: -1}, nil
+	}
+
+	if ct, ok := c.complexTypes[local]; ok {
+		return ct, nil, nil
+	}
+	if node, ok := c.complexNodes[local]; ok {
+		ct := &complexType{}
+		c.complexTypes[local] = ct
+		if _, err := c.compileComplexType(node, ct); err != nil {
+			return nil, nil, fmt.Errorf("tipo '%s': %w", local, err)
+		}
+		return ct, nil, nil
+	}
+	if st, ok := c.simpleTypes[local]; ok {
+		return nil, st, nil
+	}
+	if node, ok := c.simpleNodes[local]; ok {
+		st, err := c.compileSimpleType(node)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tipo '%s': %w", local, err)
+		}
+		c.simpleTypes[local] = st
+		return nil, st, nil
+	}
+	return nil, nil, fmt.Errorf("tipo '%s' no declarado", qname)
+}
+
+// resolveSimpleType retorna el tipo simple de un QName
+func (c *schemaCompiler) resolveSimpleType(qname string) (*simpleType, error) {
+	_, simple, err := c.resolveType(qname)
+	if err != nil {
+		return nil, err
+	}
+	if simple == nil {
+		return nil, fmt.Errorf("'%s' no es un tipo simple", qname)
+	}
+	return simple, nil
+}
+
+// compileComplexType completa ct con los atributos y el contenido del nodo
+func (c *schemaCompiler) compileComplexType(node xmlNode, ct *complexType) (*complexType, error) {
+	for _, child := range node.Children {
+		switch child.XMLName.Local {
+		case "annotation":
+		case "sequence", "choice":
+			if ct.content != nil {
+				return nil, fmt.Errorf("más de un modelo de contenido")
+			}
+			content, err := c.compileParticle(child)
+			if err != nil {
+				return nil, err
+			}
+			ct.content = content
+		case "attribute":
+			attr, err := c.compileAttribute(child)
+			if err != nil {
+				return nil, err
+			}
+			if attr != nil {
+				ct.attributes = append(ct.attributes, attr)
+			}
+		case "anyAttribute":
+			ct.anyAttribute = true
+		default:
+			return nil, fmt.Errorf("xs:%s no soportado", child.XMLName.Local)
+		}
+	}
+	return ct, nil
+}
+
+// compileParticle compila un elemento local, una secuencia, una elección o
+// un comodín
+func (c *schemaCompiler) compileParticle(node xmlNode) (*particle, error) {
+	p := &particle{min: 1, max: 1}
+	if v := node.attr("minOccurs"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("minOccurs '%s' inválido", v)
+		}
+		p.min = n
+	}
+	if v := node.attr("maxOccurs"); v == "unbounded" {
+		p.max = unbounded
+	} else if v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < p.min {
+			return nil, fmt.Errorf("maxOccurs '%s' inválido", v)
+		}
+		p.max = n
+	}
+
+	switch node.XMLName.Local {
+	case "element":
+		p.kind = particleElement
+		if ref := node.attr("ref"); ref != "" {
+			element, err := c.globalElement(ref[strings.IndexByte(ref, ':')+1:])
+			if err != nil {
+				return nil, err
+			}
+			p.element = element
+			return p, nil
+		}
+		name := node.attr("name")
+		if name == "" {
+			return nil, fmt.Errorf("xs:element sin name ni ref")
+		}
+		p.element = &schemaElement{name: name}
+		if err := c.elementType(p.element, node); err != nil {
+			return nil, err
+		}
+
+	case "sequence", "choice":
+		p.kind = particleSequence
+		if node.XMLName.Local == "choice" {
+			p.kind = particleChoice
+		}
+		for _, child := range node.Children {
+			if child.XMLName.Local == "annotation" {
+				continue
+			}
+			sub, err := c.compileParticle(child)
+			if err != nil {
+				return nil, err
+			}
+			p.children = append(p.children, sub)
+		}
+
+	case "any":
+		p.kind = particleAny
+
+	default:
+		return nil, fmt.Errorf("xs:%s no soportado en un modelo de contenido", node.XMLName.Local)
+	}
+	return p, nil
+}
+
+// compileAttribute compila un atributo. Los atributos del espacio xml (como
+// xml:lang) se admiten siempre y no se declaran
+func (c *schemaCompiler) compileAttribute(node xmlNode) (*schemaAttribute, error) {
+	if ref := node.attr("ref"); ref != "" {
+		if strings.HasPrefix(ref, "xml:") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("atributo ref='%s' no soportado", ref)
+	}
+
+	attr := &schemaAttribute{name: node.attr("name"), required: node.attr("use") == "required"}
+	if attr.name == "" {
+		return nil, fmt.Errorf("xs:attribute sin name")
+	}
+
+	var err error
+	if typeName := node.attr("type"); typeName != "" {
+		attr.typ, err = c.resolveSimpleType(typeName)
+	} else {
+		attr.typ = &simpleType{builtin: "anySimpleType", maxLength: -1}
+		for _, child := range node.Children {
+			if child.XMLName.Local == "simpleType" {
+				attr.typ, err = c.compileSimpleType(child)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("atributo '%s': %w", attr.name, err)
+	}
+	return attr, nil
+}
+
+// compileSimpleType compila un xs:simpleType definido por restricción
+func (c *schemaCompiler) compileSimpleType(node xmlNode) (*simpleType, error) {
+	var restriction *xmlNode
+	for i, child := range node.Children {
+		switch child.XMLName.Local {
+		case "annotation":
+		case "restriction":
+			restriction = &node.Children[i]
+		default:
+			return nil, fmt.Errorf("xs:%s no soportado", child.XMLName.Local)
+		}
+	}
+	if restriction == nil {
+		return nil, fmt.Errorf("xs:simpleType sin xs:restriction")
+	}
+
+	st := &simpleType{maxLength: -1}
+	var err error
+	if base := restriction.attr("base"); base != "" {
+		st.base, err = c.resolveSimpleType(base)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var patterns []string
+	for _, facet := range restriction.Children {
+		value := facet.attr("value")
+		switch facet.XMLName.Local {
+		case "annotation", "whiteSpace":
+		case "simpleType":
+			if st.base, err = c.compileSimpleType(facet); err != nil {
+				return nil, err
+			}
+		case "enumeration":
+			st.enumeration = append(st.enumeration, value)
+		case "pattern":
+			patterns = append(patterns, value)
+		case "length", "minLength", "maxLength":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("xs:%s '%s' inválido", facet.XMLName.Local, value)
+			}
+			if facet.XMLName.Local != "maxLength" {
+				st.minLength = n
+			}
+			if facet.XMLName.Local != "minLength" {
+				st.maxLength = n
+			}
+		case "minInclusive", "maxInclusive":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("xs:%s '%s' inválido", facet.XMLName.Local, value)
+			}
+			if facet.XMLName.Local == "minInclusive" {
+				st.minInclusive = &n
+			} else {
+				st.maxInclusive = &n
+			}
+		default:
+			return nil, fmt.Errorf("faceta xs:%s no soportada", facet.XMLName.Local)
+		}
+	}
+	if st.base == nil {
+		return nil, fmt.Errorf("xs:restriction sin base")
+	}
+
+	// Los patrones de XSD siempre abarcan el valor completo
+	if len(patterns) > 0 {
+		st.patternText = strings.Join(patterns, "|")
+		st.pattern, err = regexp.Compile("^(?:" + strings.Join(patterns, ")$|^(?:") + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("xs:pattern '%s': %w", st.patternText, err)
+		}
+	}
+	return st, nil
+}
+
+// check retorna por qué el valor no es válido para el tipo, o "" si lo es
+func (t *simpleType) check(value string) string {
+	if t.base != nil {
+		if msg := t.base.check(value); msg != "" {
+			return msg
+		}
+	}
+	if !t.preservesSpace() {
+		value = strings.TrimSpace(value)
+	}
+
+	if t.builtin != "" && !builtinTypes[t.builtin](value) {
+		return fmt.Sprintf("no es un valor xs:%s", t.builtin)
+	}
+
+	length := utf8.RuneCountInString(value)
+	switch {
+	case length < t.minLength && length == 0:
+		return "no puede estar vacío"
+	case length < t.minLength:
+		return fmt.Sprintf("debe tener al menos %d caracteres", t.minLength)
+	case t.maxLength >= 0 && length > t.maxLength:
+		return fmt.Sprintf("debe tener como máximo %d caracteres", t.maxLength)
+	}
+
+	if len(t.enumeration) > 0 && !containsString(t.enumeration, value) {
+		return fmt.Sprintf("no es uno de: %s", strings.Join(t.enumeration, ", "))
+	}
+	if t.pattern != nil && !t.pattern.MatchString(value) {
+		return fmt.Sprintf("no cumple el patrón '%s'", t.patternText)
+	}
+
+	if t.minInclusive != nil || t.maxInclusive != nil {
+		n, err := strconv.ParseFloat(value, 64)
+		switch {
+		case err != nil:
+			return "no es un número"
+		case t.minInclusive != nil && n < *t.minInclusive:
+			return fmt.Sprintf("debe ser mayor o igual a %g", *t.minInclusive)
+		case t.maxInclusive != nil && n > *t.maxInclusive:
+			return fmt.Sprintf("debe ser menor o igual a %g", *t.maxInclusive)
+		}
+	}
+	return ""
+}
+
+// preservesSpace indica si el tipo deriva de xs:string, que conserva los
+// espacios; los demás tipos predefinidos los ignoran al inicio y al final
+func (t *simpleType) preservesSpace() bool {
+	for ; t != nil; t = t.base {
+		if t.builtin != "" {
+			return stringTypes[t.builtin]
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ===================================================================================
+// VALIDACIÓN DE DOCUMENTOS
+// ===================================================================================
+
+// docNode es un elemento del documento validado con su línea
+type docNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*docNode
+	text     strings.Builder
+	line     int
+}
+
+// ValidateFile valida un archivo XDF contra el esquema
+func (s *Schema) ValidateFile(filePath string) ([]SchemaError, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo archivo XML: %w", err)
+	}
+	defer file.Close()
+
+	violations, err := s.Validate(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("error decodificando XML '%s': %w", filePath, err)
+	}
+	return violations, nil
+}
+
+// Validate valida un documento contra el esquema. Retorna las violaciones
+// encontradas, o error si el documento no es XML bien formado
+func (s *Schema) Validate(r io.Reader) ([]SchemaError, error) {
+	root, err := parseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &schemaValidator{}
+	path := "/" + root.name
+	if decl, ok := s.elements[root.name]; ok {
+		v.element(root, decl, path)
+	} else {
+		v.report(root, path, fmt.Sprintf("elemento raíz '%s' no declarado en el esquema", root.name))
+	}
+
+	// Las violaciones de un elemento se reportan junto a las de sus hijos
+	sort.SliceStable(v.errors, func(i, j int) bool { return v.errors[i].Line < v.errors[j].Line })
+	return v.errors, nil
+}
+
+// parseDocument lee el documento como un árbol de docNode
+func parseDocument(r io.Reader) (*docNode, error) {
+	decoder := fileio.NewXMLDecoder(r)
+	var root *docNode
+	var stack []*docNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			line, _ := decoder.InputPos()
+			node := &docNode{name: t.Name.Local, attrs: t.Attr, line: line}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("el documento no tiene elementos")
+	}
+	return root, nil
+}
+
+// schemaValidator acumula las violaciones de un documento
+type schemaValidator struct {
+	errors []SchemaError
+}
+
+func (v *schemaValidator) report(node *docNode, path, message string) {
+	v.errors = append(v.errors, SchemaError{Line: node.line, Path: path, Message: message})
+}
+
+// element valida un elemento y sus hijos contra su declaración
+func (v *schemaValidator) element(node *docNode, decl *schemaElement, path string) {
+	if decl.simple != nil {
+		v.attributes(node, &complexType{}, path)
+		if len(node.children) > 0 {
+			v.report(node, path, "no admite elementos hijos")
+		}
+		text := node.text.String()
+		if msg := decl.simple.check(text); msg != "" {
+			v.report(node, path, fmt.Sprintf("valor %q: %s", text, msg))
+		}
+		return
+	}
+
+	ct := decl.complex
+	v.attributes(node, ct, path)
+	if strings.TrimSpace(node.text.String()) != "" {
+		v.report(node, path, "no admite texto")
+	}
+
+	names := make([]string, len(node.children))
+	for i, child := range node.children {
+		names[i] = child.name
+	}
+	v.content(node, ct.content, names, path)
+
+	for _, child := range node.children {
+		p := ct.content.find(child.name)
+		if p == nil || p.kind == particleAny {
+			continue
+		}
+		v.element(child, p.element, path+"/"+nodeLabel(child))
+	}
+}
+
+// attributes valida los atributos del elemento
+func (v *schemaValidator) attributes(node *docNode, ct *complexType, path string) {
+	present := make(map[string]bool, len(node.attrs))
+	for _, a := range node.attrs {
+		if a.Name.Space == "xmlns" || a.Name.Space == xmlNamespace || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		present[a.Name.Local] = true
+
+		decl := ct.attribute(a.Name.Local)
+		if decl == nil {
+			if !ct.anyAttribute {
+				v.report(node, path, fmt.Sprintf("atributo '%s' no permitido", a.Name.Local))
+			}
+			continue
+		}
+		if msg := decl.typ.check(a.Value); msg != "" {
+			v.report(node, path, fmt.Sprintf("atributo '%s' = %q: %s", a.Name.Local, a.Value, msg))
+		}
+	}
+
+	for _, decl := range ct.attributes {
+		if decl.required && !present[decl.name] {
+			v.report(node, path, fmt.Sprintf("falta el atributo requerido '%s'", decl.name))
+		}
+	}
+}
+
+// content valida la secuencia de hijos contra el modelo de contenido
+func (v *schemaValidator) content(node *docNode, content *particle, names []string, path string) {
+	if content == nil {
+		if len(names) > 0 {
+			v.report(node.children[0], path+"/"+nodeLabel(node.children[0]), "no se permiten elementos hijos aquí")
+		}
+		return
+	}
+
+	m := &contentMatcher{names: names, expected: make(map[int][]string)}
+	for _, end := range m.repeat(content, []int{0}) {
+		if end == len(names) {
+			return
+		}
+	}
+
+	expected := strings.Join(m.expected[m.furthest], ", ")
+	if m.furthest < len(names) {
+		child := node.children[m.furthest]
+		message := fmt.Sprintf("elemento '%s' no permitido aquí", child.name)
+		if expected != "" {
+			message += "; se esperaba: " + expected
+		}
+		v.report(child, path+"/"+nodeLabel(child), message)
+		return
+	}
+	v.report(node, path, "contenido incompleto; falta: "+expected)
+}
+
+// nodeLabel identifica un elemento en la ruta por su Name o Path
+func nodeLabel(node *docNode) string {
+	for _, key := range []string{"Name", "Path", "PathB"} {
+		for _, a := range node.attrs {
+			if a.Name.Local == key {
+				return fmt.Sprintf("%s[%s=%s]", node.name, key, a.Value)
+			}
+		}
+	}
+	return node.name
+}
+
+// attribute retorna la declaración de un atributo, o nil si no existe
+func (ct *complexType) attribute(name string) *schemaAttribute {
+	for _, attr := range ct.attributes {
+		if attr.name == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+// find retorna la partícula que valida un hijo con ese nombre: su elemento o
+// un comodín. Retorna nil si el modelo no lo admite
+func (p *particle) find(name string) *particle {
+	if p == nil {
+		return nil
+	}
+	switch p.kind {
+	case particleElement:
+		if p.element.name == name {
+			return p
+		}
+	case particleAny:
+		return p
+	default:
+		var wildcard *particle
+		for _, child := range p.children {
+			if found := child.find(name); found != nil {
+				if found.kind == particleElement {
+					return found
+				}
+				wildcard = found
+			}
+		}
+		return wildcard
+	}
+	return nil
+}
+
+// contentMatcher recorre el modelo de contenido como un autómata: cada paso
+// retorna las posiciones de la lista de hijos a las que se puede llegar.
+// furthest y expected permiten reportar dónde y qué se esperaba al fallar
+type contentMatcher struct {
+	names    []string
+	furthest int
+	expected map[int][]string
+}
+
+// repeat aplica la partícula entre min y max veces desde cada posición
+func (m *contentMatcher) repeat(p *particle, positions []int) []int {
+	var result []int
+	if p.min == 0 {
+		result = append(result, positions...)
+	}
+	current := positions
+	for count := 1; len(current) > 0 && (p.max == unbounded || count <= p.max); count++ {
+		// Una partícula que puede no consumir hijos no se repite sin fin
+		if count > p.min && count > len(m.names)+1 {
+			break
+		}
+		var next []int
+		for _, pos := range current {
+			next = append(next, m.once(p, pos)...)
+		}
+		current = uniquePositions(next)
+		if count >= p.min {
+			result = append(result, current...)
+		}
+	}
+
+	result = uniquePositions(result)
+	for _, pos := range result {
+		if pos > m.furthest {
+			m.furthest = pos
+		}
+	}
+	return result
+}
+
+// once aplica la partícula una vez desde pos
+func (m *contentMatcher) once(p *particle, pos int) []int {
+	switch p.kind {
+	case particleElement:
+		if pos < len(m.names) && m.names[pos] == p.element.name {
+			return []int{pos + 1}
+		}
+		if !containsString(m.expected[pos], p.element.name) {
+			m.expected[pos] = append(m.expected[pos], p.element.name)
+		}
+		return nil
+
+	case particleAny:
+		if pos < len(m.names) {
+			return []int{pos + 1}
+		}
+		return nil
+
+	case particleSequence:
+		positions := []int{pos}
+		for _, child := range p.children {
+			positions = m.repeat(child, positions)
+			if len(positions) == 0 {
+				break
+			}
+		}
+		return positions
+
+	default:
+		var positions []int
+		for _, child := range p.children {
+			positions = append(positions, m.repeat(child, []int{pos})...)
+		}
+		return uniquePositions(positions)
+	}
+}
+
+// uniquePositions elimina las posiciones repetidas conservando el orden
+func uniquePositions(positions []int) []int {
+	if len(positions) < 2 {
+		return positions
+	}
+	seen := make(map[int]bool, len(positions))
+	unique := make([]int, 0, len(positions))
+	for _, pos := range positions {
+		if !seen[pos] {
+			seen[pos] = true
+			unique = append(unique, pos)
+		}
+	}
+	return unique
+}
+
+// SchemaReport es el resultado de validar archivos XDF con xdf-validate
+type SchemaReport struct {
+	Schema  string       `json:"schema"`
+	Invalid int          `json:"invalid"`
+	Files   []SchemaFile `json:"files"`
+}
+
+// SchemaFile son las violaciones de un archivo. Error indica que el archivo
+// no se pudo leer o no es XML bien formado
+type SchemaFile struct {
+	File   string        `json:"file"`
+	Valid  bool          `json:"valid"`
+	Error  string        `json:"error,omitempty"`
+	Errors []SchemaError `json:"errors"`
+}
+
+// ValidateFiles valida cada archivo contra el esquema
+func (s *Schema) ValidateFiles(files []string) *SchemaReport {
+	report := &SchemaReport{Schema: s.Source, Files: make([]SchemaFile, 0, len(files))}
+	for _, file := range files {
+		result := SchemaFile{File: file, Errors: []SchemaError{}}
+		violations, err := s.ValidateFile(file)
+		if err != nil {
+			result.Error = err.Error()
+		} else if len(violations) > 0 {
+			result.Errors = violations
+		} else {
+			result.Valid = true
+		}
+		if !result.Valid {
+			report.Invalid++
+		}
+		report.Files = append(report.Files, result)
+	}
+	return report
+}
+
+// Print escribe el reporte en texto: una línea por violación y el resultado
+// de cada archivo
+func (r *SchemaReport) Print(w io.Writer) {
+	for _, f := range r.Files {
+		for _, e := range f.Errors {
+			fmt.Fprintf(w, "%s:%d: %s: %s\n", f.File, e.Line, e.Path, e.Message)
+		}
+	}
+
+	for _, f := range r.Files {
+		switch {
+		case f.Error != "":
+			fmt.Fprintf(w, "%s: %s\n", f.File, f.Error)
+		case f.Valid:
+			fmt.Fprintf(w, "%s: cumple el esquema\n", f.File)
+		default:
+			fmt.Fprintf(w, "%s: %d violaciones\n", f.File, len(f.Errors))
+		}
+	}
+	fmt.Fprintf(w, "%d archivos, %d no cumplen el esquema (%s)\n", len(r.Files), r.Invalid, r.Schema)
+}
+
+// WriteJSON escribe el reporte en JSON
+func (r *SchemaReport) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializando reporte: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ===================================================================================
+// VALIDACIÓN DE LOS XDF GENERADOS
+// ===================================================================================
+
+// maxLoggedSchemaErrors limita las violaciones que se registran por archivo
+const maxLoggedSchemaErrors = 20
+
+// validateXDF valida un XDF antes de escribirlo. Con force las violaciones
+// se registran como advertencia y el archivo se escribe igual
+func validateXDF(fileName string, xdf XDF, schema *Schema, force bool) error {
+	data, err := xml.Marshal(xdf)
+	if err != nil {
+		return fmt.Errorf("error codificando XML: %w", err)
+	}
+	violations, err := schema.Validate(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error validando '%s': %w", fileName, err)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for i, e := range violations {
+		if i == maxLoggedSchemaErrors {
+			log.Printf("[ERROR] %s: ... y %d violaciones más", fileName, len(violations)-i)
+			break
+		}
+		log.Printf("[ERROR] %s: %s: %s", fileName, e.Path, e.Message)
+	}
+	if force {
+		log.Printf("[WARN] --force: se escribe '%s' aunque no cumple el esquema (%s)", fileName, schema.Source)
+		return nil
+	}
+	return fmt.Errorf("%w (%s): '%s' tiene %d violaciones", ErrSchema, schema.Source, fileName, len(violations))
+}
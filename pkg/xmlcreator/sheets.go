@@ -0,0 +1,97 @@
+// pkg/xmlcreator/sheets.go
+package xmlcreator
+
+import (
+	"errors"
+	"fmt"
+	"goScadaSur/pkg/fileio"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+// Estados posibles del procesamiento de una hoja
+const (
+	sheetOK      = "OK"
+	sheetSkipped = "OMITIDA"
+	sheetFailed  = "ERROR"
+)
+
+// SheetSummary resume el resultado del procesamiento de una hoja Excel
+type SheetSummary struct {
+	Sheet    string
+	Status   string
+	Stations []StationSummary
+	Err      error
+}
+
+// CreateXMLFromSheets procesa cada hoja indicada como un dataset
+// independiente. Las hojas sin datos o sin las columnas requeridas se omiten;
+// un error en una hoja no detiene el resto. Retorna error si alguna hoja falló
+func CreateXMLFromSheets(inputFilePath string, sheets []string, opts Options) error {
+	if err := opts.loadSchema(); err != nil {
+		return err
+	}
+	summaries := make([]SheetSummary, 0, len(sheets))
+	usedNames := make(map[string]bool)
+	failed := 0
+
+	for _, sheet := range sheets {
+		log.Printf("[INFO] ── Hoja '%s' ──", sheet)
+
+		sheetOpts := opts
+		sheetOpts.Read.Sheet = sheet
+		stations, err := createXML(inputFilePath, sheetOpts, usedNames)
+		summary := SheetSummary{Sheet: sheet, Status: sheetOK, Stations: stations, Err: err}
+
+		switch {
+		case err == nil:
+		case errors.Is(err, fileio.ErrNoData), errors.Is(err, fileio.ErrMissingColumns):
+			log.Printf("[WARN] Hoja '%s' omitida: %v", sheet, err)
+			summary.Status = sheetSkipped
+		default:
+			log.Printf("[ERROR] Hoja '%s': %v", sheet, err)
+			summary.Status = sheetFailed
+			failed++
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	printSheetSummary(summaries)
+
+	if failed > 0 {
+		return fmt.Errorf("%d de %d hojas terminaron con error", failed, len(sheets))
+	}
+
+	// Las estaciones de la línea base se buscan en todas las hojas (con una
+	// hoja fallida no se sabría cuáles se eliminaron)
+	if opts.Baseline != nil {
+		if _, err := opts.Baseline.saveRemoved(opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printSheetSummary imprime una tabla con el resultado de cada hoja
+func printSheetSummary(summaries []SheetSummary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nHOJA\tESTADO\tESTACIONES\tFILAS\tARCHIVOS\tDETALLE")
+
+	for _, s := range summaries {
+		rows, files := 0, 0
+		for _, station := range s.Stations {
+			rows += station.Rows
+			files += len(station.Files)
+		}
+
+		detail := "-"
+		if s.Err != nil {
+			detail = s.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", s.Sheet, s.Status, len(s.Stations), rows, files, detail)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,122 @@
+// pkg/xmlcreator/station.go
+package xmlcreator
+
+import (
+	"fmt"
+	"goScadaSur/pkg/fileio"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// StationKey identifica una estación por su jerarquía EMPRESA/REGION/B1/B2/B3
+type StationKey struct {
+	Empresa string
+	Region  string
+	B1      string
+	B2      string
+	B3      string
+}
+
+// String retorna la clave en formato EMPRESA/REGION/B1/B2/B3
+func (k StationKey) String() string {
+	return strings.Join([]string{k.Empresa, k.Region, k.B1, k.B2, k.B3}, "/")
+}
+
+// stationKeyFromRow construye la clave de estación a partir de una fila
+func stationKeyFromRow(row []string, headerMap map[string]int) StationKey {
+	return StationKey{
+		Empresa: fileio.GetCellValue(row, headerMap["EMPRESA"]),
+		Region:  fileio.GetCellValue(row, headerMap["REGION"]),
+		B1:      fileio.GetCellValue(row, headerMap["B1"]),
+		B2:      fileio.GetCellValue(row, headerMap["B2"]),
+		B3:      fileio.GetCellValue(row, headerMap["B3"]),
+	}
+}
+
+// stationGroup acumula el procesamiento de las filas de una estación
+type stationGroup struct {
+	Key  StationKey
+	Rows int
+	proc *rowProcessor
+}
+
+// groupRowsByStation consume las filas del reader de forma incremental,
+// procesando cada una en el grupo de su estación. Conserva el orden de
+// aparición de las estaciones y retorna el total de filas leídas
+func groupRowsByStation(reader fileio.DataReader, headerMap map[string]int, naming *NamingRules) ([]*stationGroup, int, error) {
+	var groups []*stationGroup
+	index := make(map[StationKey]*stationGroup)
+	total := 0
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return groups, total, err
+		}
+		if len(row.Values) == 0 {
+			continue
+		}
+		total++
+
+		key := stationKeyFromRow(row.Values, headerMap)
+		group, exists := index[key]
+		if !exists {
+			group = &stationGroup{Key: key, proc: newRowProcessor(headerMap, naming)}
+			index[key] = group
+			groups = append(groups, group)
+		}
+
+		group.Rows++
+		if err := group.proc.process(row.Number, row.Values); err != nil {
+			return groups, total, fmt.Errorf("error procesando estación '%s': %w", key, err)
+		}
+	}
+
+	return groups, total, nil
+}
+
+// stationFileBase retorna el prefijo de archivo para una estación, evitando
+// colisiones entre estaciones con el mismo B3 en distintas ramas
+func stationFileBase(key StationKey, used map[string]bool) string {
+	base := key.B3
+	if used[base] {
+		base = fmt.Sprintf("%s_%s", key.B2, key.B3)
+	}
+	for i := 2; used[base]; i++ {
+		base = fmt.Sprintf("%s_%s_%d", key.B2, key.B3, i)
+	}
+	used[base] = true
+	return base
+}
+
+// StationSummary resume lo generado para una estación
+type StationSummary struct {
+	Station      StationKey
+	Rows         int
+	IFSPoints    int
+	IMMElements  int
+	BreakerLinks int
+	Files        []string
+}
+
+// printSummary imprime una tabla con el resumen de archivos generados
+func printSummary(summaries []StationSummary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nESTACIÓN\tFILAS\tIFS\tIMM\tENLACES\tARCHIVOS")
+
+	for _, s := range summaries {
+		files := "-"
+		if len(s.Files) > 0 {
+			files = strings.Join(s.Files, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n",
+			s.Station, s.Rows, s.IFSPoints, s.IMMElements, s.BreakerLinks, files)
+	}
+
+	w.Flush()
+}
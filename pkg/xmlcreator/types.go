@@ -1,7 +1,10 @@
 // pkg/xmlcreator/types.go
 package xmlcreator
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"fmt"
+)
 
 // ===================================================================================
 // ESTRUCTURAS PARA ELEMENTOS XML
@@ -117,9 +120,9 @@ type Link_TerminalMeasuredByMeasurement struct {
 
 // LinkedTerminal representa un terminal con enlaces
 type LinkedTerminal struct {
-	XMLName xml.Name `xml:"Terminal"`
-	Name    string   `xml:"Name,attr"`
-	Links   []Link_TerminalMeasuredByMeasurement
+	XMLName xml.Name                             `xml:"Terminal"`
+	Name    string                               `xml:"Name,attr"`
+	Links   []Link_TerminalMeasuredByMeasurement `xml:"Link_TerminalMeasuredByMeasurement"`
 }
 
 // ===================================================================================
@@ -144,3 +147,54 @@ type Parent struct {
 	Path     string `xml:"Path,attr"`
 	Elements []any  `xml:",any"`
 }
+
+// UnmarshalXML decodifica los elementos de un Parent según su nombre, con los
+// mismos tipos que se usan al generarlo. Los elementos desconocidos se ignoran
+func (p *Parent) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "Path" {
+			p.Path = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			var element any
+			switch t.Name.Local {
+			case "Analog":
+				element = &Analog{}
+			case "Discrete":
+				element = &Discrete{}
+			case "Breaker":
+				element = &Breaker{}
+			case "IfsPoint":
+				element = &IfsPoint{}
+			case "Terminal":
+				element = &LinkedTerminal{}
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := d.DecodeElement(element, &t); err != nil {
+				return fmt.Errorf("error leyendo %s en '%s': %w", t.Name.Local, p.Path, err)
+			}
+			// Los terminales enlazados se generan por valor
+			if terminal, ok := element.(*LinkedTerminal); ok {
+				element = *terminal
+			}
+			p.Elements = append(p.Elements, element)
+
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// pkg/xmlcreator/validation.go
+package xmlcreator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goScadaSur/pkg/config"
+	"goScadaSur/pkg/fileio"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrValidation indica que las filas de entrada no cumplen validation.rules
+var ErrValidation = errors.New("errores de validación en los datos")
+
+// Violation es un valor que no cumple una regla de validación
+type Violation struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Value   string `json:"value"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationRules contiene las reglas de validation.rules compiladas
+type ValidationRules struct {
+	columns []columnRule
+}
+
+// columnRule es la regla compilada de una columna
+type columnRule struct {
+	column  string
+	rule    config.ColumnRule
+	pattern *regexp.Regexp
+}
+
+// NewValidationRules compila las reglas de la sección validation
+func NewValidationRules(cfg config.ValidationConfig) (*ValidationRules, error) {
+	rules := &ValidationRules{}
+
+	for column, rule := range cfg.Rules {
+		compiled := columnRule{column: column, rule: rule}
+		if rule.Type == "regex" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("regla de validación '%s' inválida: %w", column, err)
+			}
+			compiled.pattern = pattern
+		}
+		rules.columns = append(rules.columns, compiled)
+	}
+
+	// Orden estable para que el reporte sea reproducible
+	sort.Slice(rules.columns, func(i, j int) bool {
+		return rules.columns[i].column < rules.columns[j].column
+	})
+
+	return rules, nil
+}
+
+// Check retorna las violaciones de una fila. Las columnas ausentes del
+// archivo se tratan como vacías
+func (v *ValidationRules) Check(rowNum int, row []string, headerMap map[string]int) []Violation {
+	var violations []Violation
+
+	value := func(column string) string {
+		idx, ok := headerMap[column]
+		if !ok {
+			return ""
+		}
+		return fileio.GetCellValue(row, idx)
+	}
+
+	for _, c := range v.columns {
+		val := value(c.column)
+		add := func(rule, format string, args ...any) {
+			violations = append(violations, Violation{
+				Row:     rowNum,
+				Column:  c.column,
+				Value:   val,
+				Rule:    rule,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if val == "" {
+			if c.rule.Required {
+				add("required", "valor requerido")
+			} else if cond := c.rule.RequiredIf; cond != nil {
+				other := value(cond.Column)
+				if other != "" && (len(cond.Values) == 0 || slices.Contains(cond.Values, other)) {
+					add("required_if", "valor requerido cuando %s es '%s'", cond.Column, other)
+				}
+			}
+			continue
+		}
+
+		switch c.rule.Type {
+		case "int":
+			n, err := strconv.ParseInt(val, 10, 64)
+			switch {
+			case err != nil:
+				add("int", "se esperaba un entero")
+			case c.rule.Min != nil && n < *c.rule.Min:
+				add("min", "menor que el mínimo %d", *c.rule.Min)
+			case c.rule.Max != nil && n > *c.rule.Max:
+				add("max", "mayor que el máximo %d", *c.rule.Max)
+			}
+		case "enum":
+			if !slices.Contains(c.rule.Values, val) {
+				add("enum", "valor no permitido (use %s)", strings.Join(c.rule.Values, ", "))
+			}
+		case "regex":
+			if !c.pattern.MatchString(val) {
+				add("regex", "no coincide con el patrón %s", c.rule.Pattern)
+			}
+		}
+	}
+
+	return violations
+}
+
+// validatingReader valida cada fila de datos a medida que se lee
+type validatingReader struct {
+	fileio.DataReader
+	rules      *ValidationRules
+	headerMap  map[string]int
+	violations []Violation
+}
+
+// Next lee la siguiente fila y registra sus violaciones
+func (r *validatingReader) Next() (fileio.Row, error) {
+	row, err := r.DataReader.Next()
+	if err != nil || len(row.Values) == 0 {
+		return row, err
+	}
+	r.violations = append(r.violations, r.rules.Check(row.Number, row.Values, r.headerMap)...)
+	return row, nil
+}
+
+// writeValidationReport escribe las violaciones en formato csv o json
+func writeValidationReport(path, format string, violations []Violation) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(violations, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error serializando reporte de validación: %w", err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("error escribiendo reporte de validación '%s': %w", path, err)
+		}
+		return nil
+	}
+
+	rows := make([][]string, len(violations))
+	for i, v := range violations {
+		rows[i] = []string{strconv.Itoa(v.Row), v.Column, v.Value, v.Rule, v.Message}
+	}
+	if err := fileio.WriteCSVWithHeaders(path, []string{"FILA", "COLUMNA", "VALOR", "REGLA", "MENSAJE"}, rows); err != nil {
+		return fmt.Errorf("error escribiendo reporte de validación '%s': %w", path, err)
+	}
+	return nil
+}